@@ -0,0 +1,231 @@
+package discord_bot
+
+import (
+	"discordgo-basic/discord_bot/handlers"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// CheckResult is the outcome of one step of Check.
+type CheckResult struct {
+	Name string
+	Err  error
+}
+
+// CheckReport lists everything Check validated.
+type CheckReport []CheckResult
+
+// OK reports whether every step passed.
+func (r CheckReport) OK() bool {
+	for _, result := range r {
+		if result.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r CheckReport) String() string {
+	var report strings.Builder
+	failed := 0
+	for _, result := range r {
+		if result.Err == nil {
+			fmt.Fprintf(&report, "ok    %v\n", result.Name)
+			continue
+		}
+		failed++
+		fmt.Fprintf(&report, "FAIL  %v\n", result.Name)
+		for _, line := range strings.Split(result.Err.Error(), "\n") {
+			fmt.Fprintf(&report, "        %v\n", line)
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(&report, "%d of %d checks failed", failed, len(r))
+	} else {
+		fmt.Fprintf(&report, "all %d checks passed", len(r))
+	}
+	return report.String()
+}
+
+// Check validates everything New sets up without connecting to Discord: the config, every command
+// definition, the commands registered in each guild, and the response templates rendered with sample data.
+// loadErr is the error ConfigLoader.Load returned along with cfg, such as unknown fields in the config file
+// or invalid environment variables, it is reported as part of the config check.
+func Check(cfg *Config, loadErr error) CheckReport {
+	var report CheckReport
+	add := func(name string, err error) {
+		report = append(report, CheckResult{Name: name, Err: err})
+	}
+
+	if loadErr == nil {
+		loadErr = cfg.Validate()
+	}
+	add("config", loadErr)
+
+	keys := make([]Command, 0, len(commands))
+	for key := range commands {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+	for _, key := range keys {
+		command := *commands[key]
+		if command.Name == "" {
+			command.Name = sanitizeCommandName(key)
+		}
+		err := validateCommand(&command)
+		if _, ok := commandHandlers[key]; !ok {
+			err = errors.Join(err, errors.New("no handler is registered"))
+		}
+		add("command /"+command.Name, err)
+	}
+
+	// problems with the sets are part of the config check
+	sets, _ := resolveCommandSets(cfg)
+	guilds := make([]string, 0, len(sets))
+	for guildID := range sets {
+		guilds = append(guilds, guildID)
+	}
+	sort.Strings(guilds)
+	for _, guildID := range guilds {
+		names := make([]string, len(sets[guildID]))
+		for n, key := range sets[guildID] {
+			names[n] = "/" + string(key)
+		}
+		scope := "global commands"
+		if guildID != "" {
+			scope = "commands in guild " + guildID
+		}
+		var err error
+		if len(names) > 100 {
+			err = fmt.Errorf("%d commands, Discord allows at most 100", len(names))
+		}
+		add(fmt.Sprintf("%v: %v", scope, strings.Join(names, " ")), err)
+	}
+
+	catalog, err := handlers.LoadCatalog(cfg.TemplateDir)
+	if err == nil {
+		err = catalog.Validate()
+	}
+	add("response templates", err)
+
+	return report
+}
+
+var commandName = regexp.MustCompile(`^[-_\p{L}\p{N}]{1,32}$`)
+
+// Discord's limits on application commands.
+const (
+	commandDescriptionLimit = 100
+	commandOptionsLimit     = 25
+	commandChoicesLimit     = 25
+	commandLengthLimit      = 4000
+)
+
+// validateCommand checks the command against the rules Discord enforces when registering it.
+func validateCommand(command *discordgo.ApplicationCommand) error {
+	var problems []string
+	length := utf8.RuneCountInString(command.Name)
+
+	if !commandName.MatchString(command.Name) {
+		problems = append(problems, fmt.Sprintf("name %q must be 1-32 letters, digits, - or _", command.Name))
+	}
+
+	switch command.Type {
+	case discordgo.ChatApplicationCommand, 0:
+		if command.Name != strings.ToLower(command.Name) {
+			problems = append(problems, fmt.Sprintf("name %q must be lowercase", command.Name))
+		}
+		if n := utf8.RuneCountInString(command.Description); n < 1 || n > commandDescriptionLimit {
+			problems = append(problems, fmt.Sprintf("description must be 1-%d characters, not %d", commandDescriptionLimit, n))
+		}
+		length += utf8.RuneCountInString(command.Description)
+		length += validateOptions("", command.Options, &problems)
+	default:
+		if command.Description != "" {
+			problems = append(problems, "user and message commands can't have a description")
+		}
+		if len(command.Options) > 0 {
+			problems = append(problems, "user and message commands can't have options")
+		}
+	}
+
+	if length > commandLengthLimit {
+		problems = append(problems, fmt.Sprintf("names, descriptions and choices add up to %d characters, at most %d are allowed", length, commandLengthLimit))
+	}
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// validateOptions appends every problem with the options to problems and returns their combined length.
+func validateOptions(prefix string, options []*discordgo.ApplicationCommandOption, problems *[]string) int {
+	report := func(format string, args ...any) {
+		*problems = append(*problems, prefix+fmt.Sprintf(format, args...))
+	}
+
+	if len(options) > commandOptionsLimit {
+		report("%d options, at most %d are allowed", len(options), commandOptionsLimit)
+	}
+
+	length := 0
+	seen := make(map[string]bool, len(options))
+	optional := false
+	for _, option := range options {
+		if option == nil {
+			report("nil option")
+			continue
+		}
+		name := option.Name
+		length += utf8.RuneCountInString(name) + utf8.RuneCountInString(option.Description)
+
+		if !commandName.MatchString(name) || name != strings.ToLower(name) {
+			report("option name %q must be 1-32 lowercase letters, digits, - or _", name)
+		}
+		if seen[name] {
+			report("option %q is defined twice", name)
+		}
+		seen[name] = true
+		if n := utf8.RuneCountInString(option.Description); n < 1 || n > commandDescriptionLimit {
+			report("option %q: description must be 1-%d characters, not %d", name, commandDescriptionLimit, n)
+		}
+
+		switch option.Type {
+		case discordgo.ApplicationCommandOptionSubCommand, discordgo.ApplicationCommandOptionSubCommandGroup:
+			length += validateOptions(prefix+name+": ", option.Options, problems)
+			continue
+		}
+
+		if option.Required && optional {
+			report("required option %q must come before the optional ones", name)
+		}
+		optional = optional || !option.Required
+
+		if len(option.Choices) > 0 {
+			switch option.Type {
+			case discordgo.ApplicationCommandOptionString, discordgo.ApplicationCommandOptionInteger, discordgo.ApplicationCommandOptionNumber:
+			default:
+				report("option %q: only string, integer and number options can have choices", name)
+			}
+			if option.Autocomplete {
+				report("option %q: can't have both choices and autocomplete", name)
+			}
+			if len(option.Choices) > commandChoicesLimit {
+				report("option %q: %d choices, at most %d are allowed", name, len(option.Choices), commandChoicesLimit)
+			}
+			for _, choice := range option.Choices {
+				if n := utf8.RuneCountInString(choice.Name); n < 1 || n > commandDescriptionLimit {
+					report("option %q: choice name %q must be 1-%d characters", name, choice.Name, commandDescriptionLimit)
+				}
+				length += utf8.RuneCountInString(choice.Name) + utf8.RuneCountInString(fmt.Sprint(choice.Value))
+			}
+		}
+	}
+	return length
+}
@@ -0,0 +1,56 @@
+package discord_bot
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testToken has the shape of a bot token of application 123456789012345678.
+const testToken = "MTIzNDU2Nzg5MDEyMzQ1Njc4.GaBcDe.abcdefghijklmnopqrstuvwxyz0123"
+
+// writeConfig writes a config file to a temporary directory and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestCheckReportsLoadErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		env    map[string]string
+		ok     bool
+	}{
+		{"valid", "token: " + testToken, nil, true},
+		{"unknown field", "token: " + testToken + "\nbogus_field: 1", nil, false},
+		{"invalid environment variable", "token: " + testToken, map[string]string{"REMOVE_COMMANDS": "maybe"}, false},
+		{"invalid setting", "token: " + testToken + "\nlog_level: loud", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			path := writeConfig(t, tt.config)
+			cfg, err := LoadConfig(flag.NewFlagSet("test", flag.ContinueOnError), []string{"-config", path})
+			if cfg == nil {
+				t.Fatal(err)
+			}
+
+			report := Check(cfg, err)
+			if report[0].Name != "config" || (report[0].Err == nil) != tt.ok {
+				t.Errorf("config check = %v, want ok %v", report[0].Err, tt.ok)
+			}
+			if report.OK() != tt.ok {
+				t.Errorf("report.OK() = %v, want %v\n%v", report.OK(), tt.ok, report)
+			}
+		})
+	}
+}
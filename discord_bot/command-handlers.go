@@ -5,19 +5,114 @@ import (
 	"discordgo-basic/discord_bot/handlers"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
-	"log"
+	"github.com/charmbracelet/log"
 	"regexp"
+	"slices"
 )
 
-var commandHandlers = map[Command]func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate){
-	helloCommand: func(b *BotImpl, bot *discordgo.Session, i *discordgo.InteractionCreate) {
+var commandHandlers = map[Command]handler{
+	helloCommand: func(b *BotImpl, bot *discordgo.Session, i *discordgo.InteractionCreate) error {
 		handlers.Responses[handlers.HelloResponse].(handlers.NewResponseType)(bot, i)
+		return nil
 	},
+	helpCommand:     showHelp,
+	incidentCommand: lookupIncident,
+	statusCommand:   showStatus,
+	reloadCommand:   reloadConfig,
 }
 
-var autocompleteHandlers = map[Command]func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate){}
+var autocompleteHandlers = map[Command]handler{}
 
-var modalHandlers = map[Command]func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate){}
+var modalHandlers = map[Command]handler{
+	Command(handlers.PaginationJumpModal): func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+		handlers.HandlePaginationModal(s, i.Interaction)
+		return nil
+	},
+}
+
+func lookupIncident(b *BotImpl, bot *discordgo.Session, i *discordgo.InteractionCreate) error {
+	id := getOpts(i.ApplicationCommandData())[incidentIDOption].StringValue()
+	notFound := handlers.UserError("incident_not_found", fmt.Sprintf("No incident with the ID %v was found. Only the most recent incidents are kept.", handlers.InlineCode(id)))
+	incident, ok := handlers.Incidents.Lookup(id)
+	if !ok {
+		return notFound
+	}
+
+	// the store holds the incidents of every guild: admins only see their own guild's, the owners see all of them.
+	// Other guilds' incidents are reported as not found so that their IDs can't be probed.
+	if i.GuildID == "" || incident.GuildID != i.GuildID {
+		owner, err := b.isOwner(handlers.UserID(i.Interaction))
+		if err != nil {
+			return handlers.InternalError("owner_lookup_failed", err)
+		}
+		if !owner {
+			return notFound
+		}
+	}
+
+	handlers.Responses[handlers.EphemeralContent].(handlers.MsgResponseType)(bot, i.Interaction, handlers.IncidentEmbed(i.GuildID, incident))
+	return nil
+}
+
+// helpPageSize is how many commands /help lists per page.
+const helpPageSize = 5
+
+func showHelp(b *BotImpl, bot *discordgo.Session, i *discordgo.InteractionCreate) error {
+	cfg := b.Config()
+	sets, err := resolveCommandSets(cfg)
+	if err != nil {
+		return handlers.InternalError("", err)
+	}
+	keys := sets[cfg.GuildID]
+	if i.GuildID != cfg.GuildID {
+		keys = append(slices.Clip(keys), sets[i.GuildID]...)
+	}
+
+	pages := make(handlers.StaticPages, 0, (len(keys)+helpPageSize-1)/helpPageSize)
+	for start := 0; start < len(keys); start += helpPageSize {
+		embed := handlers.NewEmbed(i.GuildID, handlers.StyleInfo).Title("Commands")
+		for _, key := range keys[start:min(start+helpPageSize, len(keys))] {
+			command := commandDefinition(cfg, key)
+			embed.Field("/"+command.Name, command.Description, false)
+		}
+		pages = append(pages, handlers.Page{Embed: embed})
+	}
+
+	_, err = handlers.Paginate(bot, i.Interaction, pages, handlers.PaginatorOptions{})
+	return err
+}
+
+func showStatus(b *BotImpl, bot *discordgo.Session, i *discordgo.InteractionCreate) error {
+	handlers.Responses[handlers.EphemeralContent].(handlers.MsgResponseType)(bot, i.Interaction, handlers.StatusEmbed(i.GuildID, b.health.Statuses()))
+	return nil
+}
+
+func reloadConfig(b *BotImpl, bot *discordgo.Session, i *discordgo.InteractionCreate) error {
+	owner, err := b.isOwner(handlers.UserID(i.Interaction))
+	if err != nil {
+		return handlers.InternalError("owner_lookup_failed", err)
+	}
+	if !owner {
+		return handlers.PermissionError("not_owner", "Only the owners of the bot can reload its configuration.")
+	}
+
+	// syncing the commands can take longer than Discord waits for a response
+	handlers.Responses[handlers.EphemeralThink].(handlers.NewResponseType)(bot, i)
+
+	report, err := b.Reload()
+	if report == nil {
+		return handlers.UserError("reload_failed", "The configuration was not reloaded:\n"+handlers.CodeBlock("", err.Error()))
+	}
+	embed := reloadEmbed(i.GuildID, report)
+	if err != nil {
+		embed.Color(handlers.ThemeFor(i.GuildID).Color(handlers.StyleError)).
+			Description("Some changes could not be applied:\n" + handlers.CodeBlock("", err.Error()))
+	}
+	handlers.InteractionLogger(i.Interaction).Info("Reloaded configuration", "applied", report.Applied, "restart_required", report.Restart)
+
+	handlers.Responses[handlers.EditInteractionResponse].(handlers.MsgReturnType)(bot, i.Interaction, embed)
+	return nil
+}
 
 func getOpts(data discordgo.ApplicationCommandInteractionData) map[CommandOption]*discordgo.ApplicationCommandInteractionDataOption {
 	options := data.Options
@@ -78,7 +173,7 @@ func sanitizeTooltip(input string) string {
 	sanitizedTooltip := tooltipRegex.FindStringSubmatch(input)
 
 	if sanitizedTooltip != nil {
-		log.Printf("Removing tooltip: %#v", sanitizedTooltip)
+		log.Debug("Removing tooltip", "match", sanitizedTooltip)
 
 		switch {
 		case sanitizedTooltip[1] != "":
@@ -86,7 +181,7 @@ func sanitizeTooltip(input string) string {
 		case sanitizedTooltip[3] != "":
 			input = sanitizedTooltip[3]
 		}
-		log.Printf("Sanitized input: %v", input)
+		log.Debug("Sanitized input", "input", input)
 	}
 	return input
 }
@@ -102,16 +197,5 @@ func sanitizeTooltip(input string) string {
 //	   MaxLength   int            `json:"max_length,omitempty"`
 //	}
 func getModalData(data discordgo.ModalSubmitInteractionData) map[handlers.Component]*discordgo.TextInput {
-	var options = make(map[handlers.Component]*discordgo.TextInput)
-	for _, actionRow := range data.Components {
-		for _, c := range actionRow.(*discordgo.ActionsRow).Components {
-			switch c := c.(type) {
-			case *discordgo.TextInput:
-				options[handlers.Component(c.CustomID)] = c
-			default:
-				log.Fatalf("Wrong component type: %T, skipping...", c)
-			}
-		}
-	}
-	return options
+	return handlers.ModalInputs(data)
 }
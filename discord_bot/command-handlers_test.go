@@ -0,0 +1,74 @@
+package discord_bot
+
+import (
+	"discordgo-basic/discord_bot/handlers"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// incidentLookup returns an /incident interaction for the ID, in the guild or in DMs if guildID is empty.
+func incidentLookup(guildID, userID, id string) *discordgo.InteractionCreate {
+	i := &discordgo.Interaction{
+		ID:      snowflakeAt(time.Now()),
+		AppID:   "1000",
+		Token:   "interaction-token",
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: guildID,
+		Data: discordgo.ApplicationCommandInteractionData{
+			Name: string(incidentCommand),
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{
+				{Name: string(incidentIDOption), Type: discordgo.ApplicationCommandOptionString, Value: id},
+			},
+		},
+	}
+	if guildID == "" {
+		i.User = &discordgo.User{ID: userID}
+	} else {
+		i.Member = &discordgo.Member{User: &discordgo.User{ID: userID}}
+	}
+	return &discordgo.InteractionCreate{Interaction: i}
+}
+
+func TestLookupIncidentIsScopedToTheGuild(t *testing.T) {
+	handlers.Incidents.Add(&handlers.Incident{ID: "GUILDAAA", Time: time.Now(), GuildID: "5000"})
+	handlers.Incidents.Add(&handlers.Incident{ID: "OTHERBBB", Time: time.Now(), GuildID: "6000"})
+	b := &BotImpl{config: &Config{Owners: []string{"9000"}}}
+
+	tests := []struct {
+		name    string
+		guildID string
+		userID  string
+		id      string
+		found   bool
+	}{
+		{"admin, own guild", "5000", "4000", "GUILDAAA", true},
+		{"admin, other guild", "5000", "4000", "OTHERBBB", false},
+		{"DM", "", "4000", "GUILDAAA", false},
+		{"owner, other guild", "5000", "9000", "OTHERBBB", true},
+		{"owner, DM", "", "9000", "GUILDAAA", true},
+		{"unknown", "5000", "9000", "NOTHERE", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, session := newFakeDiscord(t)
+			err := lookupIncident(b, session, incidentLookup(tt.guildID, tt.userID, tt.id))
+
+			if tt.found {
+				if err != nil || len(fake.received()) != 1 {
+					t.Errorf("lookupIncident() = %v after %v requests, want the incident shown", err, len(fake.received()))
+				}
+				return
+			}
+			var e *handlers.Error
+			if !errors.As(err, &e) || e.Code != "incident_not_found" {
+				t.Errorf("lookupIncident() = %v, want incident_not_found", err)
+			}
+			if len(fake.received()) != 0 {
+				t.Errorf("sent %v requests, want none", len(fake.received()))
+			}
+		})
+	}
+}
@@ -1,6 +1,11 @@
 package discord_bot
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
 	"github.com/bwmarrin/discordgo"
 )
 
@@ -16,14 +21,22 @@ var (
 )
 
 const (
-	helloCommand Command = "hello"
+	helloCommand    Command = "hello"
+	helpCommand     Command = "help"
+	incidentCommand Command = "incident"
+	statusCommand   Command = "status"
+	reloadCommand   Command = "reload"
 )
 
 const (
 	// Command options
-	promptOption CommandOption = "prompt"
+	promptOption     CommandOption = "prompt"
+	incidentIDOption CommandOption = "id"
 )
 
+// adminPermission restricts commands to server administrators by default
+var adminPermission int64 = discordgo.PermissionAdministrator
+
 var commands = map[Command]*discordgo.ApplicationCommand{
 	helloCommand: {
 		Name: string(helloCommand),
@@ -33,6 +46,100 @@ var commands = map[Command]*discordgo.ApplicationCommand{
 		Description: "Say hello to the bot",
 		Type:        discordgo.ChatApplicationCommand,
 	},
+	helpCommand: {
+		Name:        string(helpCommand),
+		Description: "List the bot's commands",
+		Type:        discordgo.ChatApplicationCommand,
+	},
+	incidentCommand: {
+		Name:                     string(incidentCommand),
+		Description:              "Look up the details of an error by its incident ID",
+		Type:                     discordgo.ChatApplicationCommand,
+		DefaultMemberPermissions: &adminPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			commandOptions[incidentIDOption],
+		},
+	},
+	statusCommand: {
+		Name:        string(statusCommand),
+		Description: "Show whether the services the bot depends on are up",
+		Type:        discordgo.ChatApplicationCommand,
+	},
+	// only the owners of the bot may use it, see BotImpl.isOwner
+	reloadCommand: {
+		Name:                     string(reloadCommand),
+		Description:              "Reload the bot's configuration without restarting it",
+		Type:                     discordgo.ChatApplicationCommand,
+		DefaultMemberPermissions: &adminPermission,
+	},
+}
+
+// sanitizeCommandName derives a command name from its key when the definition has none.
+func sanitizeCommandName(key Command) string {
+	// clean the key because it might be a description of some sort
+	// only get the first word, and clean to only alphanumeric characters or -
+	sanitized := strings.ReplaceAll(string(key), " ", "-")
+	sanitized = strings.ToLower(sanitized)
+
+	// remove all non-valid characters
+	for _, c := range sanitized {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			sanitized = strings.ReplaceAll(sanitized, string(c), "")
+		}
+	}
+	return sanitized
+}
+
+// resolveCommandSets returns the commands to register in each guild, sorted by name.
+// The commands for cfg.GuildID, which is empty for global commands, are always present.
+func resolveCommandSets(cfg *Config) (map[string][]Command, error) {
+	var problems []string
+	resolve := func(scope string, names []string) []Command {
+		if len(names) == 0 {
+			keys := make([]Command, 0, len(commands))
+			for key := range commands {
+				keys = append(keys, key)
+			}
+			sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+			return keys
+		}
+
+		keys := make([]Command, 0, len(names))
+		seen := make(map[Command]bool, len(names))
+		for _, name := range names {
+			key := Command(strings.TrimPrefix(name, "/"))
+			switch {
+			case commands[key] == nil:
+				problems = append(problems, fmt.Sprintf("%v: unknown command %q", scope, name))
+			case seen[key]:
+				problems = append(problems, fmt.Sprintf("%v: command %q is listed twice", scope, name))
+			default:
+				keys = append(keys, key)
+			}
+			seen[key] = true
+		}
+		sort.Slice(keys, func(a, b int) bool { return keys[a] < keys[b] })
+		return keys
+	}
+
+	sets := map[string][]Command{cfg.GuildID: resolve("commands", cfg.Commands)}
+	for guildID, names := range cfg.GuildCommands {
+		if guildID == cfg.GuildID {
+			problems = append(problems, fmt.Sprintf("guild %v: already the main guild, use commands instead", guildID))
+			continue
+		}
+		if len(names) == 0 {
+			problems = append(problems, fmt.Sprintf("guild %v: no commands listed", guildID))
+			continue
+		}
+		sets[guildID] = resolve("guild "+guildID, names)
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return sets, errors.New(strings.Join(problems, "\n"))
+	}
+	return sets, nil
 }
 
 var commandOptions = map[CommandOption]*discordgo.ApplicationCommandOption{
@@ -42,6 +149,12 @@ var commandOptions = map[CommandOption]*discordgo.ApplicationCommandOption{
 		Description: "The text prompt to imagine",
 		Required:    true,
 	},
+	incidentIDOption: {
+		Type:        discordgo.ApplicationCommandOptionString,
+		Name:        string(incidentIDOption),
+		Description: "The incident ID shown in the error message",
+		Required:    true,
+	},
 }
 
 const (
@@ -1,17 +1,42 @@
 package discord_bot
 
 import (
+	"context"
 	"discordgo-basic/discord_bot/handlers"
+	"errors"
 	"github.com/bwmarrin/discordgo"
 )
 
-var componentHandlers = map[handlers.Component]func(bot *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate){
-	handlers.DeleteButton: deleteMessage,
+var componentHandlers = map[handlers.Component]handler{
+	handlers.DeleteButton:  deleteMessage,
+	handlers.DismissButton: dismissMessage,
+
+	handlers.PaginationFirst:    paginate,
+	handlers.PaginationPrevious: paginate,
+	handlers.PaginationJump:     paginate,
+	handlers.PaginationNext:     paginate,
+	handlers.PaginationLast:     paginate,
 }
 
-func deleteMessage(bot *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.ChannelMessageDelete(i.ChannelID, i.Message.ID)
-	if err != nil {
-		handlers.ErrorEphemeralResponse(s, i.Interaction, err)
+func deleteMessage(bot *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	ok, err := handlers.Confirm(bot.Context(i.Interaction), s, i.Interaction, handlers.Message(i.Interaction, handlers.MessageConfirmDelete, nil))
+	switch {
+	case errors.Is(err, handlers.ErrConfirmTimeout), errors.Is(err, context.Canceled):
+		return nil
+	case err != nil:
+		return err
+	case !ok:
+		return nil
 	}
+
+	return s.ChannelMessageDelete(i.ChannelID, i.Message.ID)
+}
+
+func dismissMessage(bot *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	return handlers.DismissMessage(s, i.Interaction)
+}
+
+func paginate(bot *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate) error {
+	handlers.HandlePagination(s, i.Interaction)
+	return nil
 }
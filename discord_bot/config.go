@@ -0,0 +1,677 @@
+package discord_bot
+
+import (
+	"bytes"
+	"discordgo-basic/discord_bot/handlers"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is read when no config file is given. It's fine for it not to exist.
+const DefaultConfigFile = "config.yaml"
+
+// Config configures the bot. It is usually built by a ConfigLoader, which reads it from a YAML file,
+// environment variables and flags, in increasing order of precedence:
+//
+//	flags > environment variables > config file > defaults
+//
+// Most settings can be changed while the bot is running, see BotImpl.Reload.
+type Config struct {
+	BotToken       string `yaml:"token"`
+	GuildID        string `yaml:"guild"`
+	RemoveCommands bool   `yaml:"remove_commands"`
+	// TemplateDir overrides the embedded response templates, see handlers.Catalog for the layout.
+	TemplateDir string `yaml:"templates"`
+	// ErrorChannelID is a channel incidents are forwarded to, usually a private admin channel.
+	ErrorChannelID string `yaml:"error_channel"`
+	// ErrorWebhookURL is a webhook incidents are forwarded to. It takes precedence over ErrorChannelID.
+	ErrorWebhookURL string `yaml:"error_webhook"`
+	// HelpURL is the documentation linked from error responses, it may contain {code}.
+	HelpURL string `yaml:"help_url"`
+	// HelpURLs overrides HelpURL for specific error codes.
+	HelpURLs map[string]string `yaml:"help_urls"`
+	// Themes overrides the embed colours, by guild ID.
+	Themes map[string]ThemeColors `yaml:"themes"`
+	// Backends are polled in the background and shown by /status.
+	Backends []handlers.Backend `yaml:"backends"`
+	// HealthInterval is how often backends are polled, handlers.DefaultHealthInterval if zero.
+	HealthInterval time.Duration `yaml:"health_interval"`
+	// Commands are the names of the commands registered in GuildID, or globally. Every command if empty.
+	Commands []string `yaml:"commands"`
+	// GuildCommands registers commands in other guilds as well, by guild ID.
+	GuildCommands map[string][]string `yaml:"guild_commands"`
+	// Permissions overrides who can use a command by default, by command name. See permissionNames.
+	Permissions map[string]string `yaml:"permissions"`
+	// Cooldowns is how long a user has to wait between uses of a command, by command name.
+	Cooldowns map[string]time.Duration `yaml:"cooldowns"`
+	// Owners may use owner-only commands such as /reload. The owners of the application if empty.
+	Owners []string `yaml:"owners"`
+	// ShutdownTimeout is how long interactions in flight are waited for when the bot stops.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// LogLevel is one of debug, info, warn, error or fatal.
+	LogLevel string `yaml:"log_level"`
+	// LogFormat is text or json.
+	LogFormat string `yaml:"log_format"`
+	// LogFile is written to instead of stderr, and rotated according to LogRotation.
+	LogFile     string      `yaml:"log_file"`
+	LogRotation LogRotation `yaml:"log_rotation"`
+	Presence    Presence    `yaml:"presence"`
+	// MetricsAddr is where the expvar metrics are served at /debug/vars, such as "localhost:9090". Disabled if empty.
+	MetricsAddr string `yaml:"metrics_addr"`
+}
+
+// LogRotation configures when Config.LogFile is rotated and how many old files are kept.
+type LogRotation struct {
+	// MaxSize is the size in megabytes at which the file is rotated.
+	MaxSize int `yaml:"max_size"`
+	// MaxBackups is how many rotated files are kept, 0 keeps them all.
+	MaxBackups int `yaml:"max_backups"`
+	// MaxAge is how many days rotated files are kept, 0 keeps them regardless of age.
+	MaxAge int `yaml:"max_age"`
+	// Compress gzips rotated files.
+	Compress bool `yaml:"compress"`
+}
+
+// ThemeColors are the embed colours of a guild as hex, such as "#5865F2". Empty colours use handlers.DefaultTheme.
+type ThemeColors struct {
+	Success string `yaml:"success"`
+	Info    string `yaml:"info"`
+	Warning string `yaml:"warning"`
+	Error   string `yaml:"error"`
+}
+
+// theme parses the colours.
+func (c ThemeColors) theme() (handlers.Theme, error) {
+	var theme handlers.Theme
+	var errs []error
+	for _, color := range []struct {
+		name  string
+		value string
+		to    *int
+	}{
+		{"success", c.Success, &theme.Success},
+		{"info", c.Info, &theme.Info},
+		{"warning", c.Warning, &theme.Warning},
+		{"error", c.Error, &theme.Error},
+	} {
+		if color.value == "" {
+			continue
+		}
+		hex := strings.TrimPrefix(strings.TrimPrefix(color.value, "#"), "0x")
+		value, err := strconv.ParseUint(hex, 16, 24)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %q is not a hex colour such as #5865F2", color.name, color.value))
+			continue
+		}
+		*color.to = int(value)
+	}
+	return theme, errors.Join(errs...)
+}
+
+// guildThemes parses the themes of every guild.
+func (cfg *Config) guildThemes() (map[string]handlers.Theme, error) {
+	themes := make(map[string]handlers.Theme, len(cfg.Themes))
+	var errs []error
+	for guildID, colors := range cfg.Themes {
+		theme, err := colors.theme()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%v: %w", guildID, err))
+		}
+		themes[guildID] = theme
+	}
+	return themes, errors.Join(errs...)
+}
+
+// Presence is the status shown in the bot's profile.
+type Presence struct {
+	// Status is one of online, idle, dnd or invisible.
+	Status string `yaml:"status"`
+	// Activity is shown as e.g. "Playing <activity>", depending on ActivityType.
+	Activity string `yaml:"activity"`
+	// ActivityType is one of playing, listening, watching, competing or custom.
+	ActivityType string `yaml:"activity_type"`
+}
+
+// keepRestartSettings reverts the settings that only take effect on a restart to those of the running config,
+// and returns the names of the ones that were changed.
+func (cfg *Config) keepRestartSettings(running *Config) []string {
+	var changed []string
+	if cfg.BotToken != running.BotToken {
+		changed = append(changed, "token")
+		cfg.BotToken = running.BotToken
+	}
+	if cfg.GuildID != running.GuildID {
+		changed = append(changed, "guild")
+		cfg.GuildID = running.GuildID
+	}
+	if !reflect.DeepEqual(cfg.Backends, running.Backends) {
+		changed = append(changed, "backends")
+		cfg.Backends = running.Backends
+	}
+	if cfg.HealthInterval != running.HealthInterval {
+		changed = append(changed, "health_interval")
+		cfg.HealthInterval = running.HealthInterval
+	}
+	if cfg.LogFormat != running.LogFormat {
+		changed = append(changed, "log_format")
+		cfg.LogFormat = running.LogFormat
+	}
+	if cfg.LogFile != running.LogFile || cfg.LogRotation != running.LogRotation {
+		changed = append(changed, "log_file")
+		cfg.LogFile, cfg.LogRotation = running.LogFile, running.LogRotation
+	}
+	if cfg.MetricsAddr != running.MetricsAddr {
+		changed = append(changed, "metrics_addr")
+		cfg.MetricsAddr = running.MetricsAddr
+	}
+	return changed
+}
+
+// DefaultConfig returns the configuration used for anything that isn't set.
+func DefaultConfig() *Config {
+	return &Config{
+		HealthInterval:  handlers.DefaultHealthInterval,
+		ShutdownTimeout: 20 * time.Second,
+		LogLevel:        "info",
+		LogFormat:       "text",
+		LogRotation:     LogRotation{MaxSize: 100, MaxBackups: 5, MaxAge: 30},
+		Presence:        Presence{Status: string(discordgo.StatusOnline)},
+	}
+}
+
+// setting is a config field that can be set from an environment variable and a flag.
+type setting struct {
+	flag  string
+	env   string
+	usage string
+	// boolean settings can be passed as a flag without a value, e.g. -remove
+	boolean bool
+	// secret settings can also be read from the file named by env + "_FILE", as mounted by Docker or Kubernetes secrets
+	secret bool
+	set    func(cfg *Config, value string) error
+}
+
+var settings = []setting{
+	{flag: "token", env: "BOT_TOKEN", usage: "Bot access token", secret: true, set: func(cfg *Config, value string) error {
+		cfg.BotToken = value
+		return nil
+	}},
+	{flag: "guild", env: "GUILD_ID", usage: "Guild ID. If not passed - bot registers commands globally", set: func(cfg *Config, value string) error {
+		cfg.GuildID = value
+		return nil
+	}},
+	{flag: "remove", env: "REMOVE_COMMANDS", usage: "Delete all commands when bot exits", boolean: true, set: func(cfg *Config, value string) error {
+		remove, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not true or false", value)
+		}
+		cfg.RemoveCommands = remove
+		return nil
+	}},
+	{flag: "templates", env: "TEMPLATE_DIR", usage: "Directory with response templates overriding the defaults", set: func(cfg *Config, value string) error {
+		cfg.TemplateDir = value
+		return nil
+	}},
+	{flag: "error-channel", env: "ERROR_CHANNEL_ID", usage: "Channel ID detailed error reports are posted to", set: func(cfg *Config, value string) error {
+		cfg.ErrorChannelID = value
+		return nil
+	}},
+	{flag: "error-webhook", env: "ERROR_WEBHOOK_URL", usage: "Webhook URL detailed error reports are posted to", secret: true, set: func(cfg *Config, value string) error {
+		cfg.ErrorWebhookURL = value
+		return nil
+	}},
+	{flag: "help-url", env: "HELP_URL", usage: "Documentation linked from errors, {code} is replaced with the error code", set: func(cfg *Config, value string) error {
+		cfg.HelpURL = value
+		return nil
+	}},
+	{flag: "backends", env: "BACKENDS", usage: "Comma separated name=url pairs of backends to monitor, e.g. api=http://localhost:7860/health", set: func(cfg *Config, value string) error {
+		backends, err := parseBackends(value)
+		if err != nil {
+			return err
+		}
+		cfg.Backends = backends
+		return nil
+	}},
+	{flag: "log-level", env: "LOG_LEVEL", usage: "One of debug, info, warn, error or fatal", set: func(cfg *Config, value string) error {
+		cfg.LogLevel = value
+		return nil
+	}},
+	{flag: "shutdown-timeout", env: "SHUTDOWN_TIMEOUT", usage: "How long to wait for interactions in flight when stopping", set: func(cfg *Config, value string) error {
+		timeout, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		cfg.ShutdownTimeout = timeout
+		return nil
+	}},
+	{flag: "log-format", env: "LOG_FORMAT", usage: "Log format, text or json", set: func(cfg *Config, value string) error {
+		cfg.LogFormat = value
+		return nil
+	}},
+	{flag: "log-file", env: "LOG_FILE", usage: "Log to this file instead of stderr, rotating it by size", set: func(cfg *Config, value string) error {
+		cfg.LogFile = value
+		return nil
+	}},
+	{flag: "health-interval", env: "HEALTH_INTERVAL", usage: "How often backends are polled, e.g. 30s", set: func(cfg *Config, value string) error {
+		interval, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", value)
+		}
+		cfg.HealthInterval = interval
+		return nil
+	}},
+	{flag: "metrics-addr", env: "METRICS_ADDR", usage: "Serve metrics at /debug/vars on this address, e.g. localhost:9090", set: func(cfg *Config, value string) error {
+		cfg.MetricsAddr = value
+		return nil
+	}},
+}
+
+// flagValue records a flag so that it can be applied after the config file and environment.
+type flagValue struct {
+	value   string
+	set     bool
+	boolean bool
+}
+
+func (f *flagValue) String() string   { return f.value }
+func (f *flagValue) IsBoolFlag() bool { return f.boolean }
+func (f *flagValue) Set(value string) error {
+	f.value, f.set = value, true
+	return nil
+}
+
+// ConfigLoader loads the Config from the config file, the environment (including a .env file) and flags,
+// see Config for the precedence. Flags are parsed once, the file and environment are read again on every Load
+// so that the config can be reloaded.
+type ConfigLoader struct {
+	configFile *string
+	flags      []*flagValue
+}
+
+// NewConfigLoader registers the config flags on fs. Callers may register flags of their own on fs as well.
+func NewConfigLoader(fs *flag.FlagSet) *ConfigLoader {
+	l := &ConfigLoader{
+		configFile: fs.String("config", "", "YAML config file, "+DefaultConfigFile+" is used if it exists (env CONFIG_FILE)"),
+		flags:      make([]*flagValue, len(settings)),
+	}
+	for n, s := range settings {
+		l.flags[n] = &flagValue{boolean: s.boolean}
+		env := s.env
+		if s.secret {
+			env += " or " + s.env + "_FILE"
+		}
+		fs.Var(l.flags[n], s.flag, fmt.Sprintf("%v (env %v)", s.usage, env))
+	}
+	return l
+}
+
+// LoadConfig parses args and loads the config once, see ConfigLoader.Load.
+func LoadConfig(fs *flag.FlagSet, args []string) (*Config, error) {
+	l := NewConfigLoader(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return l.Load()
+}
+
+// Load builds the Config. The flag set must have been parsed.
+//
+// Every invalid or missing field is reported in a single *ConfigError, which is returned along with
+// the config as loaded so that it can still be inspected, see Check.
+func (l *ConfigLoader) Load() (*Config, error) {
+	// variables set in the process environment take precedence over .env
+	dotenv, err := godotenv.Read()
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("error loading .env file: %w", err)
+	}
+	getenv := func(key string) string {
+		if value, ok := os.LookupEnv(key); ok {
+			return value
+		}
+		return dotenv[key]
+	}
+
+	cfg := DefaultConfig()
+	var problems ConfigError
+
+	path := *l.configFile
+	if path == "" {
+		path = getenv("CONFIG_FILE")
+	}
+	if err := cfg.loadFile(path); err != nil {
+		problems.add("config file", err)
+	}
+
+	for _, s := range settings {
+		name, value := s.env, getenv(s.env)
+		if file := getenv(s.env + "_FILE"); s.secret && file != "" {
+			_, valueInEnv := os.LookupEnv(s.env)
+			_, fileInEnv := os.LookupEnv(s.env + "_FILE")
+			switch {
+			case value != "" && valueInEnv == fileInEnv:
+				problems.addf(s.env, "set either %v or %v_FILE, not both", s.env, s.env)
+				continue
+			case value != "" && valueInEnv:
+				// the process environment takes precedence over .env, keep the value
+			default:
+				name = s.env + "_FILE"
+				if value, err = readSecretFile(file); err != nil {
+					problems.add(name, err)
+					continue
+				}
+			}
+		}
+		if value != "" {
+			if err := s.set(cfg, value); err != nil {
+				problems.add(name, err)
+			}
+		}
+	}
+
+	for n, s := range settings {
+		if l.flags[n].set {
+			if err := s.set(cfg, l.flags[n].value); err != nil {
+				problems.add("-"+s.flag, err)
+			}
+		}
+	}
+
+	var invalid *ConfigError
+	if errors.As(cfg.Validate(), &invalid) {
+		problems.Problems = append(problems.Problems, invalid.Problems...)
+	}
+	if len(problems.Problems) > 0 {
+		return cfg, &problems
+	}
+	return cfg, nil
+}
+
+// loadFile reads the YAML file into the config. An empty path reads DefaultConfigFile if it exists.
+func (cfg *Config) loadFile(path string) error {
+	optional := path == ""
+	if optional {
+		path = DefaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if optional && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("%v: %w", path, err)
+	}
+	return nil
+}
+
+// readSecretFile reads a secret from a file, ignoring the trailing newline most editors add.
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("%v is empty", path)
+	}
+	return secret, nil
+}
+
+// ConfigError lists everything wrong with a configuration.
+type ConfigError struct {
+	Problems []string
+}
+
+func (e *ConfigError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+func (e *ConfigError) add(field string, err error) {
+	e.Problems = append(e.Problems, fmt.Sprintf("%v: %v", field, err))
+}
+
+func (e *ConfigError) addf(field, format string, args ...any) {
+	e.Problems = append(e.Problems, fmt.Sprintf("%v: "+format, append([]any{field}, args...)...))
+}
+
+var (
+	snowflake   = regexp.MustCompile(`^\d{17,20}$`)
+	tokenFormat = regexp.MustCompile(`^[A-Za-z\d_-]+\.[A-Za-z\d_-]+\.[A-Za-z\d_-]+$`)
+	// tokenPlaceholders are the values shipped in examples, they are never valid tokens
+	tokenPlaceholders = []string{"YOUR_BOT_TOKEN_HERE", "DISCORDTOKEN"}
+)
+
+// tokenApplicationID returns the ID of the application a bot token belongs to,
+// which is the base64 encoded first segment of the token.
+func tokenApplicationID(token string) (string, error) {
+	segment, _, _ := strings.Cut(token, ".")
+	segment = strings.TrimRight(segment, "=")
+	id, err := base64.RawStdEncoding.DecodeString(segment)
+	if err != nil {
+		id, err = base64.RawURLEncoding.DecodeString(segment)
+	}
+	if err != nil || !snowflake.Match(id) {
+		return "", errors.New("the first segment of the token doesn't decode to an application ID, was it copied completely?")
+	}
+	return string(id), nil
+}
+
+// Validate returns a *ConfigError listing every problem with the config, or nil if there are none.
+func (cfg *Config) Validate() error {
+	var problems ConfigError
+
+	switch {
+	case cfg.BotToken == "":
+		problems.addf("token", "missing, set BOT_TOKEN or BOT_TOKEN_FILE, pass -token or add token to the config file")
+	case slices.Contains(tokenPlaceholders, cfg.BotToken):
+		problems.addf("token", "still the placeholder %q, did you edit the .env or run the program with -token ?", cfg.BotToken)
+	case strings.HasPrefix(cfg.BotToken, "Bot "):
+		problems.addf("token", "remove the \"Bot \" prefix, it is added automatically")
+	case !tokenFormat.MatchString(cfg.BotToken):
+		problems.addf("token", "not a bot token, expected three base64 segments separated by dots")
+	default:
+		if _, err := tokenApplicationID(cfg.BotToken); err != nil {
+			problems.add("token", err)
+		}
+	}
+
+	if cfg.GuildID != "" && !snowflake.MatchString(cfg.GuildID) {
+		problems.addf("guild", "%q is not a Discord ID", cfg.GuildID)
+	}
+	if cfg.ErrorChannelID != "" && !snowflake.MatchString(cfg.ErrorChannelID) {
+		problems.addf("error_channel", "%q is not a Discord ID", cfg.ErrorChannelID)
+	}
+	if cfg.ErrorWebhookURL != "" && !isHTTPURL(cfg.ErrorWebhookURL) {
+		problems.addf("error_webhook", "not an absolute http(s) URL")
+	}
+
+	if cfg.TemplateDir != "" {
+		if info, err := os.Stat(cfg.TemplateDir); err != nil {
+			problems.add("templates", err)
+		} else if !info.IsDir() {
+			problems.addf("templates", "%v is not a directory", cfg.TemplateDir)
+		}
+	}
+
+	if err := (handlers.HelpLinks{Default: cfg.HelpURL, Codes: cfg.HelpURLs}).Validate(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			problems.addf("help_url", "%v", line)
+		}
+	}
+
+	for guildID := range cfg.Themes {
+		if !snowflake.MatchString(guildID) {
+			problems.addf("themes", "%q is not a Discord ID", guildID)
+		}
+	}
+	if _, err := cfg.guildThemes(); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			problems.addf("themes", "%v", line)
+		}
+	}
+
+	names := make(map[string]bool, len(cfg.Backends))
+	for n, backend := range cfg.Backends {
+		field := fmt.Sprintf("backends[%d]", n)
+		switch {
+		case backend.Name == "":
+			problems.addf(field, "missing name")
+		case names[backend.Name]:
+			problems.addf(field, "duplicate name %q", backend.Name)
+		}
+		names[backend.Name] = true
+		if !isHTTPURL(backend.URL) {
+			problems.addf(field, "%q is not an absolute http(s) URL", backend.URL)
+		}
+	}
+	for guildID := range cfg.GuildCommands {
+		if !snowflake.MatchString(guildID) {
+			problems.addf("guild_commands", "%q is not a Discord ID", guildID)
+		}
+	}
+	if _, err := resolveCommandSets(cfg); err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			problems.addf("commands", "%v", line)
+		}
+	}
+
+	for name, permission := range cfg.Permissions {
+		if commands[Command(name)] == nil {
+			problems.addf("permissions", "unknown command %q", name)
+		}
+		if _, ok := permissionNames[permission]; !ok {
+			problems.addf("permissions", "%v: unknown permission %q, use one of %v", name, permission, strings.Join(sortedKeys(permissionNames), ", "))
+		}
+	}
+	for name, cooldown := range cfg.Cooldowns {
+		if commands[Command(name)] == nil {
+			problems.addf("cooldowns", "unknown command %q", name)
+		}
+		if cooldown < 0 {
+			problems.addf("cooldowns", "%v: %v is negative", name, cooldown)
+		}
+	}
+	for _, owner := range cfg.Owners {
+		if !snowflake.MatchString(owner) {
+			problems.addf("owners", "%q is not a Discord ID", owner)
+		}
+	}
+	if _, err := log.ParseLevel(cfg.LogLevel); err != nil {
+		problems.addf("log_level", "%q is not one of debug, info, warn, error or fatal", cfg.LogLevel)
+	}
+	if cfg.ShutdownTimeout < 0 {
+		problems.addf("shutdown_timeout", "%v is negative", cfg.ShutdownTimeout)
+	}
+	if cfg.LogFormat != "text" && cfg.LogFormat != "json" {
+		problems.addf("log_format", "%q is not text or json", cfg.LogFormat)
+	}
+	if rotation := cfg.LogRotation; rotation.MaxSize < 0 || rotation.MaxBackups < 0 || rotation.MaxAge < 0 {
+		problems.addf("log_rotation", "max_size, max_backups and max_age can't be negative")
+	}
+	if _, ok := presenceStatuses[cfg.Presence.Status]; !ok {
+		problems.addf("presence", "unknown status %q, use one of %v", cfg.Presence.Status, strings.Join(sortedKeys(presenceStatuses), ", "))
+	}
+	if _, ok := activityTypes[cfg.Presence.ActivityType]; !ok && cfg.Presence.ActivityType != "" {
+		problems.addf("presence", "unknown activity type %q, use one of %v", cfg.Presence.ActivityType, strings.Join(sortedKeys(activityTypes), ", "))
+	}
+
+	// zero falls back to handlers.DefaultHealthInterval
+	if cfg.HealthInterval != 0 && cfg.HealthInterval < time.Second {
+		problems.addf("health_interval", "%v is shorter than 1s", cfg.HealthInterval)
+	}
+	if cfg.MetricsAddr != "" {
+		if _, _, err := net.SplitHostPort(cfg.MetricsAddr); err != nil {
+			problems.addf("metrics_addr", "%q is not a host:port address", cfg.MetricsAddr)
+		}
+	}
+
+	if len(problems.Problems) == 0 {
+		return nil
+	}
+	return &problems
+}
+
+// permissionNames are the values accepted in Config.Permissions.
+var permissionNames = map[string]*int64{
+	"everyone":         nil,
+	"administrator":    permission(discordgo.PermissionAdministrator),
+	"manage_guild":     permission(discordgo.PermissionManageServer),
+	"manage_channels":  permission(discordgo.PermissionManageChannels),
+	"manage_messages":  permission(discordgo.PermissionManageMessages),
+	"manage_roles":     permission(discordgo.PermissionManageRoles),
+	"moderate_members": permission(discordgo.PermissionModerateMembers),
+	"disabled":         permission(0),
+}
+
+func permission(p int64) *int64 {
+	return &p
+}
+
+var presenceStatuses = map[string]discordgo.Status{
+	"online":    discordgo.StatusOnline,
+	"idle":      discordgo.StatusIdle,
+	"dnd":       discordgo.StatusDoNotDisturb,
+	"invisible": discordgo.StatusInvisible,
+}
+
+var activityTypes = map[string]discordgo.ActivityType{
+	"playing":   discordgo.ActivityTypeGame,
+	"listening": discordgo.ActivityTypeListening,
+	"watching":  discordgo.ActivityTypeWatching,
+	"competing": discordgo.ActivityTypeCompeting,
+	"custom":    discordgo.ActivityTypeCustom,
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func isHTTPURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// parseBackends parses comma separated name=url pairs.
+func parseBackends(s string) ([]handlers.Backend, error) {
+	var backends []handlers.Backend
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(pair, "=")
+		if !ok || name == "" || url == "" {
+			return nil, fmt.Errorf("%q is not a name=url pair", pair)
+		}
+		backends = append(backends, handlers.Backend{Name: name, URL: url})
+	}
+	return backends, nil
+}
@@ -0,0 +1,158 @@
+package discord_bot
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets every variable the config is read from, so that the developer's environment can't leak in.
+func clearEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	for _, s := range settings {
+		t.Setenv(s.env, "")
+		t.Setenv(s.env+"_FILE", "")
+	}
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	const (
+		fileGuild = "111111111111111111"
+		envGuild  = "222222222222222222"
+		flagGuild = "333333333333333333"
+	)
+
+	tests := []struct {
+		name      string
+		file      string
+		env       map[string]string
+		args      []string
+		wantGuild string
+		wantLevel string
+		wantWait  time.Duration
+	}{
+		{"defaults", "", nil, nil, "", "info", 20 * time.Second},
+		{"file over defaults", "guild: \"" + fileGuild + "\"\nlog_level: debug\nshutdown_timeout: 5s", nil, nil, fileGuild, "debug", 5 * time.Second},
+		{
+			"environment over file",
+			"guild: \"" + fileGuild + "\"\nlog_level: debug",
+			map[string]string{"GUILD_ID": envGuild, "SHUTDOWN_TIMEOUT": "1m"},
+			nil,
+			envGuild, "debug", time.Minute,
+		},
+		{
+			"flags over environment",
+			"guild: \"" + fileGuild + "\"",
+			map[string]string{"GUILD_ID": envGuild, "LOG_LEVEL": "warn"},
+			[]string{"-guild", flagGuild, "-shutdown-timeout", "3s"},
+			flagGuild, "warn", 3 * time.Second,
+		},
+		{"empty environment variables are ignored", "log_level: error", map[string]string{"LOG_LEVEL": ""}, nil, "", "error", 20 * time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("BOT_TOKEN", testToken)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			args := append([]string{"-config", writeConfig(t, tt.file)}, tt.args...)
+
+			cfg, err := LoadConfig(flag.NewFlagSet("test", flag.ContinueOnError), args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.GuildID != tt.wantGuild || cfg.LogLevel != tt.wantLevel || cfg.ShutdownTimeout != tt.wantWait {
+				t.Errorf("guild, log level, shutdown timeout = %q, %q, %v, want %q, %q, %v",
+					cfg.GuildID, cfg.LogLevel, cfg.ShutdownTimeout, tt.wantGuild, tt.wantLevel, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestConfigSecretFiles(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(secret, []byte(testToken+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr bool
+	}{
+		{"file", map[string]string{"BOT_TOKEN_FILE": secret}, false},
+		{"file and variable", map[string]string{"BOT_TOKEN_FILE": secret, "BOT_TOKEN": testToken}, true},
+		{"missing file", map[string]string{"BOT_TOKEN_FILE": secret + ".missing"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			args := []string{"-config", writeConfig(t, "")}
+
+			cfg, err := LoadConfig(flag.NewFlagSet("test", flag.ContinueOnError), args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && cfg.BotToken != testToken {
+				t.Errorf("token = %q, want the trimmed file content", cfg.BotToken)
+			}
+		})
+	}
+}
+
+func TestTokenApplicationID(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{"eighteen digits", "MTIzNDU2Nzg5MDEyMzQ1Njc4.GaBcDe.abcdefghijklmnopqrstuvwxyz0123", "123456789012345678", false},
+		{"nineteen digits", "MTIzNDU2Nzg5MDEyMzQ1Njc4OQ.GaBcDe.abcdefghijklmnopqrstuvwxyz0123", "1234567890123456789", false},
+		{"with padding", "MTIzNDU2Nzg5MDEyMzQ1Njc4OQ==.GaBcDe.abcdefghijklmnopqrstuvwxyz0123", "1234567890123456789", false},
+		{"truncated", "MTIzNDU2Nzg5.GaBcDe.abcdefghijklmnopqrstuvwxyz0123", "", true},
+		{"not base64", "!!!.GaBcDe.abcdefghijklmnopqrstuvwxyz0123", "", true},
+		{"not an ID", "aGVsbG8gd29ybGQ.GaBcDe.abcdefghijklmnopqrstuvwxyz0123", "", true},
+		{"empty", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tokenApplicationID(tt.token)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("tokenApplicationID(%q) = %q, %v, want %q, error %v", tt.token, got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigHealthInterval(t *testing.T) {
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{"0s", false},
+		{"1s", false},
+		{"5m", false},
+		{"500ms", true},
+		{"-1s", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv("BOT_TOKEN", testToken)
+			args := []string{"-config", writeConfig(t, "health_interval: "+tt.value)}
+
+			_, err := LoadConfig(flag.NewFlagSet("test", flag.ContinueOnError), args)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadConfig() = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -1,13 +1,18 @@
 package discord_bot
 
 import (
+	"context"
 	"discordgo-basic/discord_bot/handlers"
 	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
+	"io"
+	"net/http"
 	"os"
 	"os/signal"
-	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/charmbracelet/log"
 )
@@ -16,15 +21,32 @@ type BotImpl struct {
 	botSession         *discordgo.Session
 	guildID            string
 	registeredCommands map[Command]*discordgo.ApplicationCommand
-	imagineCommand     *Command
-	config             *Config
+	// guildCommands are the commands registered in guilds other than guildID, see Config.GuildCommands
+	guildCommands  map[string]map[Command]*discordgo.ApplicationCommand
+	imagineCommand *Command
+	logger         *log.Logger
+	logFile        io.Closer
+	health         *handlers.HealthMonitor
+	metrics        *http.Server
+	cooldowns      *handlers.Cooldowns
+
+	// mu guards config and loader, use Config to read the config
+	mu     sync.RWMutex
+	config *Config
+	loader *ConfigLoader
+	// reloadMu serializes reloads and command registration
+	reloadMu sync.Mutex
+
+	// inflight are the interactions being handled, by ID. drained is created when the bot starts shutting down,
+	// and closed once inflight is empty. See Shutdown.
+	inflightMu sync.Mutex
+	inflight   map[string]*inflight
+	drained    chan struct{}
 }
 
-type Config struct {
-	BotToken       string
-	GuildID        string
-	RemoveCommands bool
-}
+// handler handles an interaction. A returned error is rendered to the user by the dispatcher,
+// use the handlers.Error constructors to control what the user sees.
+type handler func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate) error
 
 func New(cfg *Config) (*BotImpl, error) {
 	if cfg.BotToken == "" {
@@ -32,30 +54,94 @@ func New(cfg *Config) (*BotImpl, error) {
 	}
 
 	handlers.Token = &cfg.BotToken
+	handlers.AddSecret(cfg.BotToken)
+	handlers.AddSecret(cfg.ErrorWebhookURL)
+
+	// every logger writes through this one, which scrubs secrets from every line
+	logger, logFile := NewLogger(cfg)
+	useLogger(logger)
+
+	catalog, err := handlers.LoadCatalog(cfg.TemplateDir)
+	if err != nil {
+		return nil, err
+	}
+	if err := catalog.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid response templates:\n%w", err)
+	}
+	handlers.SetCatalog(catalog)
+
+	if err := handlers.SetHelpLinks(handlers.HelpLinks{Default: cfg.HelpURL, Codes: cfg.HelpURLs}); err != nil {
+		return nil, err
+	}
+
+	themes, err := cfg.guildThemes()
+	if err != nil {
+		return nil, err
+	}
+	handlers.SetGuildThemes(themes)
 
 	if cfg.GuildID == "" {
 		//return nil, errors.New("missing guild ID")
-		log.Printf("Guild ID not provided, commands will be registered globally")
+		logger.Warn("Guild ID not provided, commands will be registered globally")
 	}
 
 	botSession, err := discordgo.New("Bot " + cfg.BotToken)
 	if err != nil {
 		return nil, err
 	}
+	botSession.Client.Transport = handlers.RedactingTransport(botSession.Client.Transport)
+
+	handlers.SetErrorReporter(newErrorReporter(botSession, cfg))
+
+	var metrics *http.Server
+	if cfg.MetricsAddr != "" {
+		metrics, err = serveMetrics(cfg.MetricsAddr, logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bot := &BotImpl{
+		botSession:         botSession,
+		guildID:            cfg.GuildID,
+		registeredCommands: make(map[Command]*discordgo.ApplicationCommand),
+		config:             cfg,
+		logger:             logger,
+		logFile:            logFile,
+		health:             handlers.NewHealthMonitor(cfg.Backends...),
+		metrics:            metrics,
+		cooldowns:          handlers.NewCooldowns(),
+		inflight:           make(map[string]*inflight),
+	}
 
 	botSession.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
-		log.Printf("Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
+		logger.Info("Logged in", "user", s.State.User.Username+"#"+s.State.User.Discriminator, "id", s.State.User.ID)
+		bot.checkApplicationID(r)
+		// the presence is reset on every new connection
+		if err := s.UpdateStatusComplex(presenceData(bot.Config().Presence)); err != nil {
+			logger.Error("Error setting presence", "err", err)
+		}
 	})
 	err = botSession.Open()
 	if err != nil {
 		return nil, err
 	}
 
-	bot := &BotImpl{
-		botSession:         botSession,
-		registeredCommands: make(map[Command]*discordgo.ApplicationCommand),
-		config:             cfg,
+	if cfg.HealthInterval > 0 {
+		bot.health.Interval = cfg.HealthInterval
 	}
+	bot.health.OnTransition(func(status handlers.BackendStatus) {
+		reporter := handlers.CurrentErrorReporter()
+		if reporter == nil {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := reporter.Notify(ctx, handlers.TransitionEmbed(cfg.GuildID, status)); err != nil {
+			logger.Error("Error sending backend status", "backend", status.Name, "err", err)
+		}
+	})
+	bot.health.Start()
 
 	err = bot.registerCommands()
 	if err != nil {
@@ -69,7 +155,20 @@ func New(cfg *Config) (*BotImpl, error) {
 
 func (b *BotImpl) registerHandlers(session *discordgo.Session) {
 	session.AddHandler(func(session *discordgo.Session, i *discordgo.InteractionCreate) {
-		var h func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate)
+		// interactions awaited by a handler take priority over the global handlers
+		if handlers.Collect(session, i.Interaction) {
+			return
+		}
+
+		logger := handlers.InteractionLogger(i.Interaction)
+
+		if !b.track(i.Interaction) {
+			rejectInteraction(session, i.Interaction)
+			return
+		}
+		defer b.untrack(i.Interaction)
+
+		var h handler
 		var ok bool
 		switch i.Type {
 		// commands
@@ -77,7 +176,6 @@ func (b *BotImpl) registerHandlers(session *discordgo.Session) {
 			h, ok = commandHandlers[Command(i.ApplicationCommandData().Name)]
 		// buttons
 		case discordgo.InteractionMessageComponent:
-			log.Printf("Component with customID `%v` was pressed, attempting to respond\n", i.MessageComponentData().CustomID)
 			h, ok = componentHandlers[handlers.Component(i.MessageComponentData().CustomID)]
 		// autocomplete
 		case discordgo.InteractionApplicationCommandAutocomplete:
@@ -86,7 +184,7 @@ func (b *BotImpl) registerHandlers(session *discordgo.Session) {
 		case discordgo.InteractionModalSubmit:
 			h, ok = modalHandlers[Command(i.ModalSubmitData().CustomID)]
 		default:
-			log.Printf("Unknown interaction type '%v'", i.Type)
+			logger.Warn("Unknown interaction type", "type", i.Type)
 		}
 
 		if !ok || h == nil {
@@ -107,66 +205,123 @@ func (b *BotImpl) registerHandlers(session *discordgo.Session) {
 					if !opt.Focused {
 						continue
 					}
-					interactionName = fmt.Sprintf("command: /%v option: %v (%v)", data.Name, opt.Name)
+					interactionName = fmt.Sprintf("command: /%v option: %v (%v)", data.Name, opt.Name, opt.Value)
 					break
 				}
 			case discordgo.InteractionModalSubmit:
 				interactionType = "modal"
 				interactionName = i.ModalSubmitData().CustomID
 			}
-			log.Printf("WARNING: Cannot find handler for interaction [%v] '%v'", interactionType, interactionName)
+			logger.Warn("Cannot find handler for interaction", "type", interactionType, "name", interactionName)
 			return
 		}
 
-		h(b, session, i)
+		logger.Debug("Handling interaction")
+		if i.Type == discordgo.InteractionApplicationCommand {
+			if err := b.checkCooldown(i.Interaction); err != nil {
+				handlers.RespondError(session, i.Interaction, err)
+				return
+			}
+		}
+
+		if err := h(b, session, i); err != nil {
+			handlers.RespondError(session, i.Interaction, err)
+		}
 	})
 
-	log.Debugf("Registered handlers %v", commandHandlers)
+	b.logger.Debug("Registered handlers", "commands", len(commandHandlers), "components", len(componentHandlers), "modals", len(modalHandlers))
 }
 
+// registerCommands brings the registered commands in line with the config: every enabled command is created,
+// or updated if it exists, and commands that are no longer enabled are deleted, including from guilds that
+// have none left. It carries on past errors and returns them all.
 func (b *BotImpl) registerCommands() error {
-	b.registeredCommands = make(map[Command]*discordgo.ApplicationCommand, len(commands))
-	for key, command := range commands {
-		if command.Name == "" {
-			// clean the key because it might be a description of some sort
-			// only get the first word, and clean to only alphanumeric characters or -
-			sanitized := strings.ReplaceAll(string(key), " ", "-")
-			sanitized = strings.ToLower(sanitized)
-
-			// remove all non-valid characters
-			for _, c := range sanitized {
-				if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
-					sanitized = strings.ReplaceAll(sanitized, string(c), "")
+	cfg := b.Config()
+	sets, err := resolveCommandSets(cfg)
+	if err != nil {
+		return err
+	}
+
+	appID := b.botSession.State.User.ID
+	previous := make(map[string]map[Command]*discordgo.ApplicationCommand, len(b.guildCommands)+1)
+	previous[b.guildID] = b.registeredCommands
+	for guildID, registered := range b.guildCommands {
+		previous[guildID] = registered
+	}
+
+	var errs []error
+	current := make(map[string]map[Command]*discordgo.ApplicationCommand, len(sets))
+	for guildID, keys := range sets {
+		registered := make(map[Command]*discordgo.ApplicationCommand, len(keys))
+		current[guildID] = registered
+
+		for _, key := range keys {
+			cmd, err := b.botSession.ApplicationCommandCreate(appID, guildID, commandDefinition(cfg, key))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("cannot create '%v' command: %w", key, err))
+				if old, ok := previous[guildID][key]; ok {
+					registered[key] = old
 				}
+				continue
 			}
-			command.Name = sanitized
+			registered[key] = cmd
+
+			b.logger.Debug("Registered command", "key", key, "name", cmd.Name, "guild", guildID)
 		}
+	}
 
-		cmd, err := b.botSession.ApplicationCommandCreate(b.botSession.State.User.ID, b.guildID, command)
-		if err != nil {
-			return errors.New(fmt.Sprintf("Cannot create '%v' command: %v", command.Name, err))
+	for guildID, registered := range previous {
+		for key, cmd := range registered {
+			if _, ok := current[guildID][key]; ok {
+				continue
+			}
+			b.logger.Info("Removing command that is no longer enabled", "key", key, "name", cmd.Name, "guild", guildID)
+
+			if err := b.botSession.ApplicationCommandDelete(appID, guildID, cmd.ID); err != nil {
+				errs = append(errs, fmt.Errorf("cannot delete '%v' command: %w", cmd.Name, err))
+				if current[guildID] == nil {
+					current[guildID] = make(map[Command]*discordgo.ApplicationCommand)
+				}
+				current[guildID][key] = cmd
+			}
 		}
-		b.registeredCommands[key] = cmd
+	}
 
-		log.Debugf("Registered %v command as: /%v", key, cmd.Name)
+	b.registeredCommands = current[b.guildID]
+	if b.registeredCommands == nil {
+		b.registeredCommands = make(map[Command]*discordgo.ApplicationCommand)
 	}
+	delete(current, b.guildID)
+	b.guildCommands = current
+
+	return errors.Join(errs...)
+}
 
-	return nil
+// commandDefinition returns the command as it is registered, with its name filled in and the permissions in cfg applied.
+func commandDefinition(cfg *Config, key Command) *discordgo.ApplicationCommand {
+	command := *commands[key]
+	if command.Name == "" {
+		command.Name = sanitizeCommandName(key)
+	}
+	if permission, ok := cfg.Permissions[string(key)]; ok {
+		command.DefaultMemberPermissions = permissionNames[permission]
+	}
+	return &command
 }
 
 func (b *BotImpl) rebuildMap(
 	f func(*BotImpl) Command,
 	key *Command,
 	m map[Command]*discordgo.ApplicationCommand,
-	h map[Command]func(b *BotImpl, s *discordgo.Session, i *discordgo.InteractionCreate,
-	)) {
+	h map[Command]handler,
+) {
 	oldKey := *key
 
 	*key = f(b)
 	if *key == oldKey {
 		return
 	}
-	log.Printf("Rebuilding map for '%v' to '%v'", oldKey, *key)
+	b.logger.Info("Rebuilding command map", "from", oldKey, "to", *key)
 
 	m[*key] = m[oldKey]
 	m[*key].Name = string(*key)
@@ -176,46 +331,183 @@ func (b *BotImpl) rebuildMap(
 }
 
 func (b *BotImpl) Start() {
-	StartPolling()
+	StartPolling(b.reloadFromSignal)
 
-	err := b.teardown()
+	err := b.Shutdown()
 	if err != nil {
-		log.Printf("Error tearing down bot: %v", err)
+		b.logger.Error("Error shutting down bot", "err", err)
+	}
+	if b.logFile != nil {
+		_ = b.logFile.Close()
 	}
 }
 
-func StartPolling() {
-	log.Print("Press Ctrl+C to exit")
+// StartPolling blocks until the program is interrupted or terminated, calling reload on SIGHUP if it isn't nil.
+func StartPolling(reload func()) {
+	log.Info("Press Ctrl+C to exit")
 
 	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
+	// container runtimes send SIGTERM
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	hangup := make(chan os.Signal, 1)
+	if reload != nil {
+		signal.Notify(hangup, syscall.SIGHUP)
+		defer signal.Stop(hangup)
+	}
 
 Polling:
 	for {
 		select {
 		case <-stop:
 			break Polling
+		case <-hangup:
+			reload()
 		}
 	}
-	log.Printf("Polling stopped...\n")
+	log.Info("Polling stopped, shutting down")
 }
 
+// teardown stops the health monitor and the metrics server, removes the commands added by the bot if Config.RemoveCommands is set,
+// and closes the session. It carries on past errors, the session is always closed, and returns them all.
 func (b *BotImpl) teardown() error {
-	// Delete all commands added by the bot
-	if b.config.RemoveCommands {
-		log.Printf("Removing all commands added by bot...")
+	b.health.Stop()
 
-		for key, v := range b.registeredCommands {
-			log.Printf("Removing command [key:%v], '%v'...", key, v.Name)
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
 
-			err := b.botSession.ApplicationCommandDelete(b.botSession.State.User.ID, b.guildID, v.ID)
-			if err != nil {
-				log.Fatalf("Cannot delete '%v' command: %v", v.Name, err)
+	var errs []error
+	if b.metrics != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := b.metrics.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("cannot stop metrics server: %w", err))
+		}
+		cancel()
+	}
+	if b.Config().RemoveCommands {
+		b.logger.Info("Removing all commands added by bot")
+
+		scopes := make(map[string]map[Command]*discordgo.ApplicationCommand, len(b.guildCommands)+1)
+		scopes[b.guildID] = b.registeredCommands
+		for guildID, registered := range b.guildCommands {
+			scopes[guildID] = registered
+		}
+		for guildID, registered := range scopes {
+			ids := make(map[string]bool, len(registered))
+			for _, cmd := range registered {
+				ids[cmd.ID] = true
+			}
+			if err := removeCommands(b.logger, b.botSession, b.botSession.State.User.ID, guildID, ids); err != nil {
+				errs = append(errs, err)
 			}
 		}
 	}
 
-	return b.botSession.Close()
+	if err := b.botSession.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("cannot close session: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// removeCommands removes the commands with the given IDs from the scope, or every command of the application
+// in the scope if ids is nil. The scope is a guild ID, or "" for global commands.
+//
+// The remaining commands are written back with a single bulk overwrite. Should that fail the commands are
+// deleted one by one instead, carrying on past errors.
+func removeCommands(logger *log.Logger, s *discordgo.Session, appID, guildID string, ids map[string]bool) error {
+	logger = logger.With("scope", scopeName(guildID))
+
+	existing, err := s.ApplicationCommands(appID, guildID)
+	if err != nil {
+		return fmt.Errorf("cannot list commands in %v: %w", scopeName(guildID), err)
+	}
+
+	keep := make([]*discordgo.ApplicationCommand, 0, len(existing))
+	var remove []*discordgo.ApplicationCommand
+	for _, cmd := range existing {
+		if ids == nil || ids[cmd.ID] {
+			remove = append(remove, cmd)
+		} else {
+			keep = append(keep, cmd)
+		}
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+
+	_, err = s.ApplicationCommandBulkOverwrite(appID, guildID, keep)
+	if err == nil {
+		for _, cmd := range remove {
+			logger.Info("Removed command", "name", cmd.Name)
+		}
+		return nil
+	}
+	logger.Warn("Bulk removal of commands failed, deleting them one by one", "err", err)
+
+	var errs []error
+	for _, cmd := range remove {
+		err := s.ApplicationCommandDelete(appID, guildID, cmd.ID)
+		var restErr *discordgo.RESTError
+		if errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownApplicationCommand {
+			// already gone
+			err = nil
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("cannot delete '%v' command from %v: %w", cmd.Name, scopeName(guildID), err))
+			continue
+		}
+		logger.Info("Removed command", "name", cmd.Name)
+	}
+	return errors.Join(errs...)
+}
+
+func scopeName(guildID string) string {
+	if guildID == "" {
+		return "global commands"
+	}
+	return "guild " + guildID
+}
+
+// UnregisterCommands removes every command of the application in the guild from cfg, or every global command
+// if there is no guild, without connecting to the gateway. It cleans up after deployments that crashed before
+// they could remove their commands.
+func UnregisterCommands(cfg *Config) error {
+	logger, logFile := NewLogger(cfg)
+	useLogger(logger)
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	handlers.AddSecret(cfg.BotToken)
+
+	session, err := discordgo.New("Bot " + cfg.BotToken)
+	if err != nil {
+		return err
+	}
+	session.Client.Transport = handlers.RedactingTransport(session.Client.Transport)
+
+	app, err := session.Application("@me")
+	if err != nil {
+		return fmt.Errorf("cannot look up the application: %w", err)
+	}
+	logger.Info("Unregistering all commands", "application", app.ID, "scope", scopeName(cfg.GuildID))
+	return removeCommands(logger, session, app.ID, cfg.GuildID, nil)
+}
+
+// checkApplicationID logs an error if the token doesn't belong to the application Discord says we logged in as,
+// which means the token was mangled in a way that still let it authenticate, or the ID decoding is wrong.
+func (b *BotImpl) checkApplicationID(r *discordgo.Ready) {
+	id, err := tokenApplicationID(b.Config().BotToken)
+	if err != nil {
+		b.logger.Warn(err)
+		return
+	}
+	actual := r.User.ID
+	if r.Application != nil && r.Application.ID != "" {
+		actual = r.Application.ID
+	}
+	if id != actual {
+		b.logger.Error("The bot token belongs to a different application than the one Discord reports", "token_application", id, "application", actual)
+	}
 }
 
 func shortenString(s string) string {
@@ -0,0 +1,133 @@
+package discord_bot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+// commandsAPI answers the command endpoints of application 1000: listing returns existing, bulk overwrites
+// answer bulkStatus and deleting a command answers deleteStatus[its ID], 204 by default.
+func commandsAPI(existing []*discordgo.ApplicationCommand, bulkStatus int, deleteStatus map[string]int) func(r fakeRequest) (int, any) {
+	return func(r fakeRequest) (int, any) {
+		switch r.Method {
+		case http.MethodGet:
+			return http.StatusOK, existing
+		case http.MethodPut:
+			if bulkStatus != http.StatusOK {
+				return bulkStatus, map[string]any{"code": 50035, "message": "Invalid Form Body"}
+			}
+			return http.StatusOK, json.RawMessage(r.Body)
+		case http.MethodDelete:
+			id := r.Path[strings.LastIndex(r.Path, "/")+1:]
+			switch deleteStatus[id] {
+			case http.StatusNotFound:
+				return http.StatusNotFound, map[string]any{"code": discordgo.ErrCodeUnknownApplicationCommand, "message": "Unknown application command"}
+			case http.StatusForbidden:
+				return http.StatusForbidden, map[string]any{"code": 50001, "message": "Missing Access"}
+			}
+		}
+		return http.StatusNoContent, nil
+	}
+}
+
+func TestRemoveCommands(t *testing.T) {
+	existing := []*discordgo.ApplicationCommand{
+		{ID: "11", Name: "hello"},
+		{ID: "12", Name: "status"},
+		{ID: "13", Name: "other-bot-version"},
+	}
+	mine := map[string]bool{"11": true, "12": true}
+
+	tests := []struct {
+		name         string
+		guildID      string
+		ids          map[string]bool
+		bulkStatus   int
+		deleteStatus map[string]int
+		want         []string
+		wantKept     []string
+		wantErr      string
+	}{
+		{
+			name: "bulk overwrite", guildID: "5000", ids: mine, bulkStatus: http.StatusOK,
+			want:     []string{"GET /applications/1000/guilds/5000/commands", "PUT /applications/1000/guilds/5000/commands"},
+			wantKept: []string{"other-bot-version"},
+		},
+		{
+			name: "global commands", ids: nil, bulkStatus: http.StatusOK,
+			want:     []string{"GET /applications/1000/commands", "PUT /applications/1000/commands"},
+			wantKept: []string{},
+		},
+		{
+			name: "nothing to remove", guildID: "5000", ids: map[string]bool{"99": true}, bulkStatus: http.StatusOK,
+			want: []string{"GET /applications/1000/guilds/5000/commands"},
+		},
+		{
+			name: "one by one after the bulk overwrite failed", guildID: "5000", ids: mine, bulkStatus: http.StatusBadRequest,
+			// a command that's already gone isn't an error
+			deleteStatus: map[string]int{"11": http.StatusNotFound},
+			want: []string{
+				"GET /applications/1000/guilds/5000/commands",
+				"PUT /applications/1000/guilds/5000/commands",
+				"DELETE /applications/1000/guilds/5000/commands/11",
+				"DELETE /applications/1000/guilds/5000/commands/12",
+			},
+		},
+		{
+			name: "one by one carries on past errors", guildID: "5000", ids: nil, bulkStatus: http.StatusBadRequest,
+			deleteStatus: map[string]int{"12": http.StatusForbidden},
+			want: []string{
+				"GET /applications/1000/guilds/5000/commands",
+				"PUT /applications/1000/guilds/5000/commands",
+				"DELETE /applications/1000/guilds/5000/commands/11",
+				"DELETE /applications/1000/guilds/5000/commands/12",
+				"DELETE /applications/1000/guilds/5000/commands/13",
+			},
+			wantErr: "cannot delete 'status' command from guild 5000",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, session := newFakeDiscord(t)
+			fake.setRespond(commandsAPI(existing, tt.bulkStatus, tt.deleteStatus))
+
+			err := removeCommands(log.New(io.Discard), session, "1000", tt.guildID, tt.ids)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("removeCommands() = %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("removeCommands() = %v, want an error containing %q", err, tt.wantErr)
+			}
+
+			var got []string
+			var kept []*discordgo.ApplicationCommand
+			for _, r := range fake.received() {
+				got = append(got, r.Method+" "+r.Path)
+				if r.Method == http.MethodPut {
+					if err := json.Unmarshal([]byte(r.Body), &kept); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sent\n%v\nwant\n%v", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if tt.wantKept != nil {
+				var names []string
+				for _, cmd := range kept {
+					names = append(names, cmd.Name)
+				}
+				if !slices.Equal(names, tt.wantKept) {
+					t.Errorf("the bulk overwrite kept %v, want %v", names, tt.wantKept)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package discord_bot
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeRequest is a request received by fakeDiscord.
+type fakeRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fakeDiscord stands in for the Discord REST API, like the one of the handlers tests. It records every request
+// and answers with respond, which defaults to 204 for everything.
+type fakeDiscord struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []fakeRequest
+	respond  func(r fakeRequest) (status int, body any)
+}
+
+// newFakeDiscord returns the fake API and a session sending every request to it.
+func newFakeDiscord(t *testing.T) (*fakeDiscord, *discordgo.Session) {
+	t.Helper()
+	f := &fakeDiscord{}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+
+	target, _ := url.Parse(f.URL)
+	session, err := discordgo.New("Bot " + testToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Client = &http.Client{Transport: rewriteHost{target: target}}
+	session.State.User = &discordgo.User{ID: "1000"}
+	return f, session
+}
+
+func (f *fakeDiscord) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := fakeRequest{Method: r.Method, Path: strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion), Body: string(body)}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	respond := f.respond
+	f.mu.Unlock()
+
+	status, response := http.StatusNoContent, any(nil)
+	if respond != nil {
+		status, response = respond(req)
+	}
+	if response == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// setRespond replaces how requests are answered.
+func (f *fakeDiscord) setRespond(respond func(r fakeRequest) (int, any)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.respond = respond
+}
+
+// received returns the requests received so far.
+func (f *fakeDiscord) received() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+type rewriteHost struct {
+	target *url.URL
+}
+
+func (t rewriteHost) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host, req.Host = t.target.Scheme, t.target.Host, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// snowflakeAt returns an ID created at t, interactions with old IDs have expired tokens.
+func snowflakeAt(t time.Time) string {
+	return strconv.FormatInt((t.UnixMilli()-1420070400000)<<22, 10)
+}
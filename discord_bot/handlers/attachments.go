@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// NamedReader is an io.Reader that knows its own file name, such as an *os.File.
+// It can be passed anywhere content is accepted and will be uploaded as an attachment.
+type NamedReader interface {
+	io.Reader
+	Name() string
+}
+
+type attachmentMode int
+
+const (
+	// KeepAttachments keeps the attachments already on a message when editing it, even if new files are added.
+	KeepAttachments attachmentMode = iota + 1
+	// ReplaceAttachments removes the attachments already on a message when editing it.
+	// This is the default when new files are provided.
+	ReplaceAttachments
+)
+
+const defaultUploadLimit int64 = 10 << 20 // 10 MiB
+
+// uploadLimits are the per-file upload limits for each guild boost tier.
+var uploadLimits = map[discordgo.PremiumTier]int64{
+	discordgo.PremiumTierNone: defaultUploadLimit,
+	discordgo.PremiumTier1:    defaultUploadLimit,
+	discordgo.PremiumTier2:    50 << 20,
+	discordgo.PremiumTier3:    100 << 20,
+}
+
+// NewFile returns a *discordgo.File that can be passed as content to any of the response helpers.
+func NewFile(name string, reader io.Reader) *discordgo.File {
+	return &discordgo.File{
+		Name:        name,
+		ContentType: mime.TypeByExtension(filepath.Ext(name)),
+		Reader:      reader,
+	}
+}
+
+// BytesFile returns a *discordgo.File backed by data.
+// If name is empty, a name is derived from the detected content type.
+func BytesFile(name string, data []byte) *discordgo.File {
+	contentType := http.DetectContentType(data)
+	if name == "" {
+		name = "attachment"
+		if extensions, _ := mime.ExtensionsByType(contentType); len(extensions) > 0 {
+			name += extensions[len(extensions)-1]
+		}
+	}
+	return &discordgo.File{
+		Name:        name,
+		ContentType: contentType,
+		Reader:      bytes.NewReader(data),
+	}
+}
+
+// toFile converts content into a *discordgo.File if it is one of the supported attachment types.
+func toFile(content any) (*discordgo.File, bool) {
+	switch c := content.(type) {
+	case *discordgo.File:
+		return c, c != nil
+	case discordgo.File:
+		return &c, true
+	case []byte:
+		return BytesFile("", c), true
+	case NamedReader:
+		return NewFile(filepath.Base(c.Name()), c), true
+	}
+	return nil, false
+}
+
+// UploadLimit returns the per-file upload limit in bytes for the guild, falling back to the default limit for DMs or unknown guilds.
+func UploadLimit(bot *discordgo.Session, guildID string) int64 {
+	if bot == nil || guildID == "" {
+		return defaultUploadLimit
+	}
+	guild, err := bot.State.Guild(guildID)
+	if err != nil {
+		guild, err = bot.Guild(guildID)
+		if err != nil {
+			return defaultUploadLimit
+		}
+	}
+	if limit, ok := uploadLimits[guild.PremiumTier]; ok {
+		return limit
+	}
+	return defaultUploadLimit
+}
+
+// checkAttachments returns an error if any of the files exceeds the upload limit of the guild.
+// Readers whose size can't be determined up front are buffered into memory so that they can still be sent.
+func checkAttachments(bot *discordgo.Session, guildID string, files []*discordgo.File) error {
+	if len(files) == 0 {
+		return nil
+	}
+	limit := UploadLimit(bot, guildID)
+	for _, file := range files {
+		size, err := fileSize(file, limit)
+		if err != nil {
+			return InternalError("attachment_unreadable", fmt.Errorf("could not read attachment %q: %w", file.Name, err))
+		}
+		if size > limit {
+			return UserError("attachment_too_large", fmt.Sprintf("The attachment %v exceeds the upload limit of %v in this server.", InlineCode(file.Name), formatBytes(limit)))
+		}
+	}
+	return nil
+}
+
+// fileSize returns the size of the file. Readers of unknown size are read up to one byte past limit, so a size
+// greater than limit only means the file is too large, and the reader is consumed.
+func fileSize(file *discordgo.File, limit int64) (int64, error) {
+	switch r := file.Reader.(type) {
+	case nil:
+		return 0, nil
+	case interface{ Len() int }:
+		return int64(r.Len()), nil
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil {
+			return 0, err
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, err
+		}
+		return info.Size() - offset, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file.Reader, limit+1))
+	if err != nil {
+		return 0, err
+	}
+	file.Reader = bytes.NewReader(data)
+	return int64(len(data)), nil
+}
+
+func formatBytes(size int64) string {
+	switch {
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", size)
+	}
+}
+
+// attachmentsFromContents returns the attachments to send with an edit based on the mode in content.
+// A nil result leaves the attachments on the message untouched.
+func attachmentsFromContents(files []*discordgo.File, existing []*discordgo.MessageAttachment, content ...any) *[]*discordgo.MessageAttachment {
+	var mode attachmentMode
+	for _, c := range content {
+		if m, ok := c.(attachmentMode); ok {
+			mode = m
+		}
+	}
+	if mode == 0 && len(files) > 0 {
+		mode = ReplaceAttachments
+	}
+
+	switch mode {
+	case ReplaceAttachments:
+		return &[]*discordgo.MessageAttachment{}
+	case KeepAttachments:
+		if existing == nil {
+			return nil
+		}
+		return &existing
+	}
+	return nil
+}
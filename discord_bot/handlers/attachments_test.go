@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// zeros is an endless reader of unknown size.
+type zeros struct{ read int64 }
+
+func (z *zeros) Read(p []byte) (int, error) {
+	clear(p)
+	z.read += int64(len(p))
+	return len(p), nil
+}
+
+func TestFileSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		reader io.Reader
+		limit  int64
+		want   int64
+	}{
+		{"nil", nil, 10, 0},
+		{"known length", bytes.NewReader(make([]byte, 42)), 10, 42},
+		{"unknown length within limit", io.MultiReader(strings.NewReader("hello")), 10, 5},
+		{"unknown length at limit", io.MultiReader(strings.NewReader("0123456789")), 10, 10},
+		{"unknown length over limit", io.MultiReader(strings.NewReader("0123456789abc")), 10, 11},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fileSize(&discordgo.File{Reader: tt.reader}, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("fileSize() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileSizeKeepsContentWithinLimit(t *testing.T) {
+	file := &discordgo.File{Reader: io.MultiReader(strings.NewReader("hello"))}
+	if _, err := fileSize(file, 10); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(file.Reader)
+	if string(data) != "hello" {
+		t.Errorf("content after fileSize = %q, want %q", data, "hello")
+	}
+}
+
+func TestCheckAttachmentsDoesNotBufferHugeReaders(t *testing.T) {
+	reader := &zeros{}
+	err := checkAttachments(nil, "", []*discordgo.File{{Name: "huge.bin", Reader: reader}})
+	if !errors.Is(err, ErrUser) {
+		t.Fatalf("checkAttachments() = %v, want a user error", err)
+	}
+	if !strings.Contains(AsError(err).Message, "huge.bin") {
+		t.Errorf("message %q doesn't name the file", AsError(err).Message)
+	}
+	if reader.read > 2*defaultUploadLimit {
+		t.Errorf("read %v bytes of a reader over the %v limit", reader.read, defaultUploadLimit)
+	}
+}
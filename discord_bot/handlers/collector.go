@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var ErrCollectorDone = errors.New("collector has ended")
+
+// CollectorOptions filters the interactions delivered to a Collector.
+// Empty fields match everything.
+type CollectorOptions struct {
+	MessageID string
+	// UserID restricts the collector to one user. Other users are told the components aren't for them.
+	UserID   string
+	CustomID *regexp.Regexp
+	// Types defaults to message components only.
+	Types []discordgo.InteractionType
+
+	// Max ends the collector after this many interactions. Zero means no limit.
+	Max int
+	// Timeout ends the collector after this long. Zero means it runs until Stop is called.
+	Timeout time.Duration
+
+	// ChannelID and MessageID are used to disable the components of the message when the collector times out.
+	// For ephemeral messages, set Interaction to the interaction whose original response is the message instead.
+	ChannelID   string
+	Interaction *discordgo.Interaction
+}
+
+// Collector delivers the interactions matching its options over C until it ends.
+// C is closed once the collector has ended.
+type Collector struct {
+	C <-chan *discordgo.Interaction
+
+	bot       *discordgo.Session
+	opts      CollectorOptions
+	c         chan *discordgo.Interaction
+	cMu       sync.Mutex
+	closed    bool // c is closed, guarded by cMu
+	done      chan struct{}
+	stopOnce  sync.Once
+	collected int
+	timer     *time.Timer
+}
+
+var (
+	collectors   = make(map[*Collector]struct{})
+	collectorsMu sync.RWMutex
+)
+
+// NewCollector registers a Collector. Call Stop when it's no longer needed.
+func NewCollector(bot *discordgo.Session, opts CollectorOptions) *Collector {
+	if len(opts.Types) == 0 {
+		opts.Types = []discordgo.InteractionType{discordgo.InteractionMessageComponent}
+	}
+
+	c := &Collector{
+		bot:  bot,
+		opts: opts,
+		c:    make(chan *discordgo.Interaction, max(opts.Max, 1)),
+		done: make(chan struct{}),
+	}
+	c.C = c.c
+
+	collectorsMu.Lock()
+	collectors[c] = struct{}{}
+	collectorsMu.Unlock()
+
+	if opts.Timeout > 0 {
+		c.timer = time.AfterFunc(opts.Timeout, func() {
+			c.Stop()
+			c.disableComponents()
+		})
+	}
+
+	return c
+}
+
+// Next waits for the next matching interaction. It returns ErrCollectorDone once the collector has ended.
+func (c *Collector) Next(ctx context.Context) (*discordgo.Interaction, error) {
+	select {
+	case i, ok := <-c.C:
+		if !ok {
+			return nil, ErrCollectorDone
+		}
+		return i, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Stop ends the collector without touching the message.
+func (c *Collector) Stop() {
+	c.stopOnce.Do(func() {
+		collectorsMu.Lock()
+		delete(collectors, c)
+		collectorsMu.Unlock()
+
+		if c.timer != nil {
+			c.timer.Stop()
+		}
+		close(c.done)
+
+		// wait for any pending deliveries to give up before closing C
+		c.cMu.Lock()
+		c.closed = true
+		close(c.c)
+		c.cMu.Unlock()
+	})
+}
+
+// Done is closed once the collector has ended.
+func (c *Collector) Done() <-chan struct{} {
+	return c.done
+}
+
+func (c *Collector) matches(i *discordgo.Interaction) bool {
+	if !slices.Contains(c.opts.Types, i.Type) {
+		return false
+	}
+	if c.opts.MessageID != "" && (i.Message == nil || i.Message.ID != c.opts.MessageID) {
+		return false
+	}
+	if c.opts.CustomID != nil && !c.opts.CustomID.MatchString(customID(i)) {
+		return false
+	}
+	return true
+}
+
+// Collect delivers the interaction to the first collector that matches it.
+// It returns true if the interaction was handled and must not be dispatched to the global handlers.
+func Collect(bot *discordgo.Session, i *discordgo.Interaction) bool {
+	collectorsMu.RLock()
+	var matched *Collector
+	for c := range collectors {
+		if c.matches(i) {
+			matched = c
+			break
+		}
+	}
+	collectorsMu.RUnlock()
+
+	if matched == nil {
+		return false
+	}
+
+	if matched.opts.UserID != "" && UserID(i) != matched.opts.UserID {
+		Responses[EphemeralContent].(MsgResponseType)(bot, i, Message(i, MessageCollectorNotOwner, nil))
+		return true
+	}
+
+	return matched.deliver(i)
+}
+
+func (c *Collector) deliver(i *discordgo.Interaction) bool {
+	collectorsMu.Lock()
+	if _, ok := collectors[c]; !ok {
+		collectorsMu.Unlock()
+		return false
+	}
+	c.collected++
+	last := c.opts.Max > 0 && c.collected >= c.opts.Max
+	if last {
+		delete(collectors, c)
+	}
+	collectorsMu.Unlock()
+
+	c.cMu.Lock()
+	if c.closed {
+		c.cMu.Unlock()
+		return false
+	}
+	select {
+	case c.c <- i:
+	case <-c.done:
+		c.cMu.Unlock()
+		return false
+	}
+	c.cMu.Unlock()
+
+	if last {
+		c.Stop()
+	}
+	return true
+}
+
+// disableComponents disables every component of the collector's message.
+func (c *Collector) disableComponents() {
+	switch {
+	case c.opts.Interaction != nil:
+		msg, err := c.bot.InteractionResponse(c.opts.Interaction)
+		if err != nil {
+			InteractionLogger(c.opts.Interaction).Error("Error fetching message to disable components", "err", err)
+			return
+		}
+		components := DisableComponents(msg.Components)
+		_, err = c.bot.InteractionResponseEdit(c.opts.Interaction, &discordgo.WebhookEdit{
+			Components: &components,
+		})
+		if err != nil {
+			InteractionLogger(c.opts.Interaction).Error("Error disabling components", "message", msg.ID, "err", err)
+		}
+	case c.opts.ChannelID != "" && c.opts.MessageID != "":
+		msg, err := c.bot.ChannelMessage(c.opts.ChannelID, c.opts.MessageID)
+		if err != nil {
+			Logger().Error("Error fetching message to disable components", "channel", c.opts.ChannelID, "message", c.opts.MessageID, "err", err)
+			return
+		}
+		components := DisableComponents(msg.Components)
+		_, err = c.bot.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			ID:         msg.ID,
+			Channel:    msg.ChannelID,
+			Components: components,
+		})
+		if err != nil {
+			Logger().Error("Error disabling components", "channel", msg.ChannelID, "message", msg.ID, "err", err)
+		}
+	}
+}
+
+func customID(i *discordgo.Interaction) string {
+	switch i.Type {
+	case discordgo.InteractionMessageComponent:
+		return i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		return i.ModalSubmitData().CustomID
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+package handlers
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestCollectorDeliverAfterStop(t *testing.T) {
+	for range 100 {
+		c := NewCollector(nil, CollectorOptions{})
+		var wg sync.WaitGroup
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				c.deliver(&discordgo.Interaction{Type: discordgo.InteractionMessageComponent})
+			}()
+		}
+		c.Stop()
+		wg.Wait()
+		if c.deliver(&discordgo.Interaction{}) {
+			t.Fatal("deliver() = true after Stop")
+		}
+	}
+}
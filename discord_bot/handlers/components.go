@@ -12,14 +12,27 @@ type Component string
 const (
 	DeleteButton Component = "delete_error_message"
 
-	dismissButton Component = "dismiss_error_message"
-	urlButton     Component = "url_button"
-	urlDelete     Component = "url_delete"
+	DismissButton Component = "dismiss_error_message"
+
+	// link buttons need a URL before they can be sent, use LinkComponent
+	urlButton Component = "url_button"
+	urlDelete Component = "url_delete"
 
 	readmoreDismiss Component = "readmore_dismiss"
 
-	paginationButtons Component = "pagination_button"
-	okCancelButtons   Component = "ok_cancel_buttons"
+	paginationButtons  Component = "pagination_button"
+	PaginationFirst    Component = paginationButtons + "_first"
+	PaginationPrevious Component = paginationButtons + "_previous"
+	PaginationJump     Component = paginationButtons + "_jump"
+	PaginationNext     Component = paginationButtons + "_next"
+	PaginationLast     Component = paginationButtons + "_last"
+
+	PaginationJumpModal Component = paginationButtons + "_jump_modal"
+	paginationPageInput Component = paginationButtons + "_page_input"
+
+	okCancelButtons Component = "ok_cancel_buttons"
+	ConfirmOK       Component = okCancelButtons + "_ok"
+	ConfirmCancel   Component = okCancelButtons + "_cancel"
 
 	Cancel    Component = "cancel"
 	Interrupt Component = "interrupt"
@@ -74,41 +87,58 @@ var Components = map[Component]discordgo.MessageComponent{
 			},
 		},
 	},
-	dismissButton: discordgo.ActionsRow{
+	DismissButton: discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
 			discordgo.Button{
 				Label:    "Dismiss",
 				Style:    discordgo.SecondaryButton,
-				CustomID: string(DeleteButton),
+				CustomID: string(DismissButton),
 			},
 		},
 	},
 	readmoreDismiss: discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
 			discordgo.Button{
-				Label:    "Read more",
-				Style:    discordgo.LinkButton,
-				CustomID: string(urlButton),
+				Label: "Read more",
+				Style: discordgo.LinkButton,
+				Emoji: &discordgo.ComponentEmoji{
+					Name: "📜",
+				},
 			},
 			discordgo.Button{
 				Label:    "Dismiss",
 				Style:    discordgo.SecondaryButton,
-				CustomID: string(DeleteButton),
+				CustomID: string(DismissButton),
 			},
 		},
 	},
 
 	paginationButtons: discordgo.ActionsRow{
 		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "First",
+				Style:    discordgo.SecondaryButton,
+				CustomID: string(PaginationFirst),
+			},
 			discordgo.Button{
 				Label:    "Previous",
 				Style:    discordgo.SecondaryButton,
-				CustomID: string(paginationButtons + "_previous"),
+				CustomID: string(PaginationPrevious),
+			},
+			discordgo.Button{
+				Label:    "Page",
+				Style:    discordgo.PrimaryButton,
+				CustomID: string(PaginationJump),
 			},
 			discordgo.Button{
 				Label:    "Next",
 				Style:    discordgo.SecondaryButton,
-				CustomID: string(paginationButtons + "_next"),
+				CustomID: string(PaginationNext),
+			},
+			discordgo.Button{
+				Label:    "Last",
+				Style:    discordgo.SecondaryButton,
+				CustomID: string(PaginationLast),
 			},
 		},
 	},
@@ -117,12 +147,12 @@ var Components = map[Component]discordgo.MessageComponent{
 			discordgo.Button{
 				Label:    "OK",
 				Style:    discordgo.SuccessButton,
-				CustomID: string(okCancelButtons + "_ok"),
+				CustomID: string(ConfirmOK),
 			},
 			discordgo.Button{
 				Label:    "Cancel",
 				Style:    discordgo.DangerButton,
-				CustomID: string(okCancelButtons + "_cancel"),
+				CustomID: string(ConfirmCancel),
 			},
 		},
 	},
@@ -219,3 +249,34 @@ func ModelSelectMenu(ID Component) discordgo.ActionsRow {
 		},
 	}
 }
+
+// DisableComponents returns a copy of components with every button and select menu disabled.
+// Link buttons are left enabled as they don't send interactions.
+func DisableComponents(components []discordgo.MessageComponent) []discordgo.MessageComponent {
+	disabled := make([]discordgo.MessageComponent, 0, len(components))
+	for _, component := range components {
+		switch c := component.(type) {
+		case discordgo.ActionsRow:
+			disabled = append(disabled, discordgo.ActionsRow{Components: DisableComponents(c.Components)})
+		case *discordgo.ActionsRow:
+			disabled = append(disabled, discordgo.ActionsRow{Components: DisableComponents(c.Components)})
+		case discordgo.Button:
+			c.Disabled = c.Style != discordgo.LinkButton
+			disabled = append(disabled, c)
+		case *discordgo.Button:
+			button := *c
+			button.Disabled = button.Style != discordgo.LinkButton
+			disabled = append(disabled, button)
+		case discordgo.SelectMenu:
+			c.Disabled = true
+			disabled = append(disabled, c)
+		case *discordgo.SelectMenu:
+			menu := *c
+			menu.Disabled = true
+			disabled = append(disabled, menu)
+		default:
+			disabled = append(disabled, c)
+		}
+	}
+	return disabled
+}
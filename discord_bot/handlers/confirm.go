@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultConfirmTimeout is how long Confirm waits for an answer when the context has no earlier deadline.
+const DefaultConfirmTimeout = time.Minute
+
+var ErrConfirmTimeout = errors.New("confirmation timed out")
+
+var confirmButtons = regexp.MustCompile(`^` + string(okCancelButtons) + `_(ok|cancel)$`)
+
+// Confirm responds to the interaction with an ephemeral prompt and the OK/Cancel buttons, then blocks until
+// the invoking user answers, ctx is done or DefaultConfirmTimeout elapses.
+// It must be used as the first response to the interaction.
+//
+// The prompt is updated to show the outcome and the buttons are disabled.
+// A timeout returns false with ErrConfirmTimeout, and a cancelled context returns false with ctx.Err().
+func Confirm(ctx context.Context, bot *discordgo.Session, i *discordgo.Interaction, prompt string) (bool, error) {
+	err := bot.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         prompt,
+			Components:      []discordgo.MessageComponent{Components[okCancelButtons]},
+			AllowedMentions: noMentions(),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+
+	msg, err := bot.InteractionResponse(i)
+	if err != nil {
+		return false, err
+	}
+
+	collector := NewCollector(bot, CollectorOptions{
+		MessageID: msg.ID,
+		UserID:    UserID(i),
+		CustomID:  confirmButtons,
+		Max:       1,
+	})
+	defer collector.Stop()
+
+	timer := time.NewTimer(DefaultConfirmTimeout)
+	defer timer.Stop()
+
+	var outcome string
+	select {
+	case answer, ok := <-collector.C:
+		if !ok {
+			return false, ErrCollectorDone
+		}
+		confirmed := Component(answer.MessageComponentData().CustomID) == ConfirmOK
+		outcome = "Cancelled"
+		if confirmed {
+			outcome = "Confirmed"
+		}
+		Responses[UpdateFromComponent].(MsgResponseType)(bot, answer,
+			confirmOutcome(prompt, outcome),
+			DisableComponents([]discordgo.MessageComponent{Components[okCancelButtons]}),
+		)
+		return confirmed, nil
+	case <-ctx.Done():
+		err = ctx.Err()
+		outcome = "Cancelled"
+	case <-timer.C:
+		err = ErrConfirmTimeout
+		outcome = "Timed out"
+	}
+
+	content := confirmOutcome(prompt, outcome)
+	components := DisableComponents([]discordgo.MessageComponent{Components[okCancelButtons]})
+	_, editErr := bot.InteractionResponseEdit(i, &discordgo.WebhookEdit{
+		Content:         &content,
+		Components:      &components,
+		AllowedMentions: noMentions(),
+	})
+	if editErr != nil {
+		InteractionLogger(i).Error("Error updating confirmation", "message", msg.ID, "err", editErr)
+	}
+	return false, err
+}
+
+func confirmOutcome(prompt, outcome string) string {
+	return prompt + "\n**" + outcome + "**"
+}
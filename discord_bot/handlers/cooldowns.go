@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"sync"
+	"time"
+)
+
+// Cooldowns tracks how long each key, such as a command and user, has to wait before it may be used again.
+type Cooldowns struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+func NewCooldowns() *Cooldowns {
+	return &Cooldowns{until: make(map[string]time.Time)}
+}
+
+// Take starts a cooldown of d for key and returns 0, unless the previous cooldown of key is still running,
+// in which case it returns the time remaining.
+func (c *Cooldowns) Take(key string, d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if until, ok := c.until[key]; ok && now.Before(until) {
+		return until.Sub(now)
+	}
+
+	// forget expired cooldowns now and then so the map doesn't grow with every user
+	if len(c.until) >= 1024 {
+		for k, until := range c.until {
+			if !now.Before(until) {
+				delete(c.until, k)
+			}
+		}
+	}
+	c.until[key] = now.Add(d)
+	return 0
+}
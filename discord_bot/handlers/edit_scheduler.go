@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultEditInterval is the minimum time between two edits of the same message.
+const DefaultEditInterval = time.Second
+
+// EditFuture is the result of an edit sent through an EditScheduler.
+// If the edit is superseded by a newer one before it was sent, it resolves with the result of the newer edit.
+type EditFuture struct {
+	done chan struct{}
+	msg  *discordgo.Message
+	err  error
+}
+
+// Done is closed once the edit, or the edit that superseded it, has landed or failed.
+func (f *EditFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait blocks until the edit has landed and returns the edited message.
+func (f *EditFuture) Wait(ctx context.Context) (*discordgo.Message, error) {
+	select {
+	case <-f.done:
+		return f.msg, f.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (f *EditFuture) resolve(msg *discordgo.Message, err error) {
+	f.msg, f.err = msg, err
+	close(f.done)
+}
+
+// EditScheduler coalesces rapid edits of the same message.
+// Only the latest pending edit of each message is kept, and it is sent no sooner than Interval after the previous
+// edit of that message. Edits of one message are sent in order, and keep coalescing while the session waits for
+// the rate limit of the route.
+type EditScheduler struct {
+	Interval time.Duration
+
+	bot      *discordgo.Session
+	mu       sync.Mutex
+	messages map[string]*scheduledMessage
+}
+
+type scheduledMessage struct {
+	pending   func() (*discordgo.Message, error)
+	futures   []*EditFuture
+	lastFlush time.Time
+	flushing  bool
+	timer     *time.Timer
+	cleanup   *time.Timer
+}
+
+// NewEditScheduler returns an EditScheduler sending edits with bot at most once per interval for each message.
+func NewEditScheduler(bot *discordgo.Session, interval time.Duration) *EditScheduler {
+	if interval <= 0 {
+		interval = DefaultEditInterval
+	}
+	return &EditScheduler{
+		Interval: interval,
+		bot:      bot,
+		messages: make(map[string]*scheduledMessage),
+	}
+}
+
+// EditInteractionResponse schedules an edit of the original response to the interaction.
+// content accepts the same types as the response helpers.
+func (s *EditScheduler) EditInteractionResponse(i *discordgo.Interaction, content ...any) *EditFuture {
+	webhookEdit := webhookFromContents(content...)
+	contentEdit(webhookEdit, content...)
+
+	return s.schedule(i.Token+"/@original", func() (*discordgo.Message, error) {
+		if err := checkAttachments(s.bot, i.GuildID, webhookEdit.Files); err != nil {
+			return nil, err
+		}
+		return responseEditRetry(s.bot, i, webhookEdit)
+	})
+}
+
+// EditFollowup schedules an edit of a followup message of the interaction.
+func (s *EditScheduler) EditFollowup(i *discordgo.Interaction, f *Followup, content ...any) *EditFuture {
+	return s.schedule(f.ID, func() (*discordgo.Message, error) {
+		return f.Edit(s.bot, content...)
+	})
+}
+
+func (s *EditScheduler) schedule(key string, edit func() (*discordgo.Message, error)) *EditFuture {
+	future := &EditFuture{done: make(chan struct{})}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, ok := s.messages[key]
+	if !ok {
+		m = &scheduledMessage{}
+		s.messages[key] = m
+	}
+	m.pending = edit
+	m.futures = append(m.futures, future)
+
+	s.arm(key, m)
+	return future
+}
+
+// arm starts a timer to flush the pending edit of the message when it is allowed to. s.mu must be held.
+func (s *EditScheduler) arm(key string, m *scheduledMessage) {
+	if m.flushing || m.timer != nil || m.pending == nil {
+		return
+	}
+	if m.cleanup != nil {
+		m.cleanup.Stop()
+		m.cleanup = nil
+	}
+
+	// the rate limit is left to the session, reading its buckets here would race with the requests using them
+	wait := time.Until(m.lastFlush.Add(s.Interval))
+	m.timer = time.AfterFunc(max(wait, 0), func() { s.flush(key) })
+}
+
+func (s *EditScheduler) flush(key string) {
+	s.mu.Lock()
+	m := s.messages[key]
+	edit, futures := m.pending, m.futures
+	m.pending, m.futures = nil, nil
+	m.timer = nil
+	m.flushing = true
+	s.mu.Unlock()
+
+	msg, err := edit()
+
+	s.mu.Lock()
+	m.flushing = false
+	m.lastFlush = time.Now()
+	if m.pending == nil {
+		// forget the message once another edit would no longer be throttled
+		m.cleanup = time.AfterFunc(s.Interval, func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.messages[key] == m && m.pending == nil && !m.flushing {
+				delete(s.messages, key)
+			}
+		})
+	} else {
+		s.arm(key, m)
+	}
+	s.mu.Unlock()
+
+	for _, future := range futures {
+		future.resolve(msg, err)
+	}
+}
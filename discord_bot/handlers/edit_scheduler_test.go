@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEditSchedulerCoalescesEdits(t *testing.T) {
+	s := NewEditScheduler(nil, 50*time.Millisecond)
+
+	var mu sync.Mutex
+	var sent []string
+	edit := func(content string) func() (*discordgo.Message, error) {
+		return func() (*discordgo.Message, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			sent = append(sent, content)
+			return &discordgo.Message{Content: content}, nil
+		}
+	}
+
+	first := s.schedule("message", edit("1"))
+	if _, err := first.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var futures []*EditFuture
+	for _, content := range []string{"2", "3", "4"} {
+		futures = append(futures, s.schedule("message", edit(content)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for n, future := range futures {
+		msg, err := future.Wait(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if msg.Content != "4" {
+			t.Errorf("edit %v resolved with %q, want the latest edit", n+2, msg.Content)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) != 2 || sent[0] != "1" || sent[1] != "4" {
+		t.Errorf("sent %v, want [1 4]", sent)
+	}
+}
@@ -0,0 +1,329 @@
+package handlers
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Embed colours matching the Discord brand palette.
+const (
+	ColorSuccess = 0x57F287
+	ColorInfo    = 0x5865F2
+	ColorWarning = 0xFEE75C
+	ColorError   = 0xED4245
+)
+
+// Discord's documented embed limits.
+const (
+	embedTitleLimit       = 256
+	embedDescriptionLimit = 4096
+	embedFieldsLimit      = 25
+	embedFieldNameLimit   = 256
+	embedFieldValueLimit  = 1024
+	embedFooterLimit      = 2048
+	embedAuthorLimit      = 256
+	embedTotalLimit       = 6000
+)
+
+type EmbedStyle int
+
+const (
+	StyleInfo EmbedStyle = iota
+	StyleSuccess
+	StyleWarning
+	StyleError
+)
+
+// Theme holds the colour used for each EmbedStyle.
+type Theme struct {
+	Success int
+	Info    int
+	Warning int
+	Error   int
+}
+
+var DefaultTheme = Theme{
+	Success: ColorSuccess,
+	Info:    ColorInfo,
+	Warning: ColorWarning,
+	Error:   ColorError,
+}
+
+var (
+	guildThemes   = make(map[string]Theme)
+	guildThemesMu sync.RWMutex
+)
+
+// SetGuildTheme overrides the embed colours for a guild. Zero colours fall back to DefaultTheme.
+func SetGuildTheme(guildID string, theme Theme) {
+	guildThemesMu.Lock()
+	defer guildThemesMu.Unlock()
+	guildThemes[guildID] = theme
+}
+
+// SetGuildThemes replaces the themes of every guild, guilds missing from themes use DefaultTheme.
+func SetGuildThemes(themes map[string]Theme) {
+	guildThemesMu.Lock()
+	defer guildThemesMu.Unlock()
+	guildThemes = make(map[string]Theme, len(themes))
+	for guildID, theme := range themes {
+		guildThemes[guildID] = theme
+	}
+}
+
+// ThemeFor returns the theme for the guild, or DefaultTheme if none was set.
+func ThemeFor(guildID string) Theme {
+	guildThemesMu.RLock()
+	theme, ok := guildThemes[guildID]
+	guildThemesMu.RUnlock()
+	if !ok {
+		return DefaultTheme
+	}
+	if theme.Success == 0 {
+		theme.Success = DefaultTheme.Success
+	}
+	if theme.Info == 0 {
+		theme.Info = DefaultTheme.Info
+	}
+	if theme.Warning == 0 {
+		theme.Warning = DefaultTheme.Warning
+	}
+	if theme.Error == 0 {
+		theme.Error = DefaultTheme.Error
+	}
+	return theme
+}
+
+func (t Theme) Color(style EmbedStyle) int {
+	switch style {
+	case StyleSuccess:
+		return t.Success
+	case StyleWarning:
+		return t.Warning
+	case StyleError:
+		return t.Error
+	default:
+		return t.Info
+	}
+}
+
+// EmbedBuilder builds a *discordgo.MessageEmbed using the guild's theme.
+// Text that exceeds Discord's limits is truncated when calling Build, use Validate to reject it instead.
+//
+// The builder can be passed directly as content to the response helpers, which also uploads
+// any files added with ImageFile or ThumbnailFile.
+type EmbedBuilder struct {
+	embed *discordgo.MessageEmbed
+	files []*discordgo.File
+}
+
+// NewEmbed returns an EmbedBuilder coloured with the style from the guild's theme.
+func NewEmbed(guildID string, style EmbedStyle) *EmbedBuilder {
+	return &EmbedBuilder{
+		embed: &discordgo.MessageEmbed{
+			Type:  discordgo.EmbedTypeRich,
+			Color: ThemeFor(guildID).Color(style),
+		},
+	}
+}
+
+func (e *EmbedBuilder) Title(title string) *EmbedBuilder {
+	e.embed.Title = title
+	return e
+}
+
+func (e *EmbedBuilder) Description(description string) *EmbedBuilder {
+	e.embed.Description = description
+	return e
+}
+
+func (e *EmbedBuilder) URL(url string) *EmbedBuilder {
+	e.embed.URL = url
+	return e
+}
+
+// Color overrides the colour from the theme.
+func (e *EmbedBuilder) Color(color int) *EmbedBuilder {
+	e.embed.Color = color
+	return e
+}
+
+func (e *EmbedBuilder) Field(name, value string, inline bool) *EmbedBuilder {
+	e.embed.Fields = append(e.embed.Fields, &discordgo.MessageEmbedField{
+		Name:   name,
+		Value:  value,
+		Inline: inline,
+	})
+	return e
+}
+
+func (e *EmbedBuilder) Footer(text, iconURL string) *EmbedBuilder {
+	e.embed.Footer = &discordgo.MessageEmbedFooter{
+		Text:    text,
+		IconURL: iconURL,
+	}
+	return e
+}
+
+func (e *EmbedBuilder) Author(name, url, iconURL string) *EmbedBuilder {
+	e.embed.Author = &discordgo.MessageEmbedAuthor{
+		Name:    name,
+		URL:     url,
+		IconURL: iconURL,
+	}
+	return e
+}
+
+// AuthorUser sets the author to the user's name and avatar.
+func (e *EmbedBuilder) AuthorUser(user *discordgo.User) *EmbedBuilder {
+	if user == nil {
+		return e
+	}
+	return e.Author(user.Username, "", user.AvatarURL(""))
+}
+
+func (e *EmbedBuilder) Timestamp(t time.Time) *EmbedBuilder {
+	e.embed.Timestamp = t.Format(time.RFC3339)
+	return e
+}
+
+func (e *EmbedBuilder) Now() *EmbedBuilder {
+	return e.Timestamp(time.Now())
+}
+
+func (e *EmbedBuilder) Image(url string) *EmbedBuilder {
+	e.embed.Image = &discordgo.MessageEmbedImage{URL: url}
+	return e
+}
+
+func (e *EmbedBuilder) Thumbnail(url string) *EmbedBuilder {
+	e.embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: url}
+	return e
+}
+
+// ImageFile uploads file alongside the embed and uses it as the embed image.
+func (e *EmbedBuilder) ImageFile(file *discordgo.File) *EmbedBuilder {
+	e.files = append(e.files, file)
+	return e.Image(attachmentURL(file))
+}
+
+// ThumbnailFile uploads file alongside the embed and uses it as the embed thumbnail.
+func (e *EmbedBuilder) ThumbnailFile(file *discordgo.File) *EmbedBuilder {
+	e.files = append(e.files, file)
+	return e.Thumbnail(attachmentURL(file))
+}
+
+// Files returns the files that must be uploaded with the embed.
+func (e *EmbedBuilder) Files() []*discordgo.File {
+	return e.files
+}
+
+func attachmentURL(file *discordgo.File) string {
+	return "attachment://" + filepath.Base(file.Name)
+}
+
+// Validate returns an error describing every part of the embed that exceeds Discord's limits.
+func (e *EmbedBuilder) Validate() error {
+	var problems []string
+	check := func(name, value string, limit int) {
+		if n := utf8.RuneCountInString(value); n > limit {
+			problems = append(problems, fmt.Sprintf("%v is %d characters long (limit %d)", name, n, limit))
+		}
+	}
+
+	check("title", e.embed.Title, embedTitleLimit)
+	check("description", e.embed.Description, embedDescriptionLimit)
+	if len(e.embed.Fields) > embedFieldsLimit {
+		problems = append(problems, fmt.Sprintf("embed has %d fields (limit %d)", len(e.embed.Fields), embedFieldsLimit))
+	}
+	for i, field := range e.embed.Fields {
+		check(fmt.Sprintf("field %d name", i+1), field.Name, embedFieldNameLimit)
+		check(fmt.Sprintf("field %d value", i+1), field.Value, embedFieldValueLimit)
+	}
+	if e.embed.Footer != nil {
+		check("footer", e.embed.Footer.Text, embedFooterLimit)
+	}
+	if e.embed.Author != nil {
+		check("author", e.embed.Author.Name, embedAuthorLimit)
+	}
+	if total := embedLength(e.embed); total > embedTotalLimit {
+		problems = append(problems, fmt.Sprintf("embed is %d characters in total (limit %d)", total, embedTotalLimit))
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid embed: %v", problems)
+	}
+	return nil
+}
+
+// Build returns the embed, truncating anything that exceeds Discord's limits.
+func (e *EmbedBuilder) Build() *discordgo.MessageEmbed {
+	embed := *e.embed
+	embed.Title = truncate(embed.Title, embedTitleLimit)
+	embed.Description = truncate(embed.Description, embedDescriptionLimit)
+
+	if len(embed.Fields) > embedFieldsLimit {
+		embed.Fields = embed.Fields[:embedFieldsLimit]
+	}
+	fields := make([]*discordgo.MessageEmbedField, len(embed.Fields))
+	for i, field := range embed.Fields {
+		fields[i] = &discordgo.MessageEmbedField{
+			Name:   truncate(field.Name, embedFieldNameLimit),
+			Value:  truncate(field.Value, embedFieldValueLimit),
+			Inline: field.Inline,
+		}
+	}
+	embed.Fields = fields
+
+	if embed.Footer != nil {
+		footer := *embed.Footer
+		footer.Text = truncate(footer.Text, embedFooterLimit)
+		embed.Footer = &footer
+	}
+	if embed.Author != nil {
+		author := *embed.Author
+		author.Name = truncate(author.Name, embedAuthorLimit)
+		embed.Author = &author
+	}
+
+	// Drop trailing fields, then shorten the description until the embed fits in the total limit
+	for embedLength(&embed) > embedTotalLimit && len(embed.Fields) > 0 {
+		embed.Fields = embed.Fields[:len(embed.Fields)-1]
+	}
+	if over := embedLength(&embed) - embedTotalLimit; over > 0 {
+		embed.Description = truncate(embed.Description, max(0, utf8.RuneCountInString(embed.Description)-over))
+	}
+
+	return &embed
+}
+
+func embedLength(embed *discordgo.MessageEmbed) int {
+	total := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	for _, field := range embed.Fields {
+		total += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	if embed.Footer != nil {
+		total += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		total += utf8.RuneCountInString(embed.Author.Name)
+	}
+	return total
+}
+
+// truncate shortens s to at most limit runes, ending with an ellipsis if anything was cut.
+func truncate(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	if limit <= 0 {
+		return ""
+	}
+	runes := []rune(s)
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEmbedBuilderBuildTruncates(t *testing.T) {
+	long := func(n int) string { return strings.Repeat("é", n) }
+	manyFields := func(n int, value string) *EmbedBuilder {
+		embed := NewEmbed("", StyleInfo)
+		for range n {
+			embed.Field("name", value, false)
+		}
+		return embed
+	}
+
+	tests := []struct {
+		name            string
+		embed           *EmbedBuilder
+		wantTitle       int
+		wantDescription int
+		wantFields      int
+	}{
+		{"within limits", NewEmbed("", StyleInfo).Title("title").Description("text"), 5, 4, 0},
+		{"long title", NewEmbed("", StyleInfo).Title(long(300)), embedTitleLimit, 0, 0},
+		{"long description", NewEmbed("", StyleInfo).Description(long(5000)), 0, embedDescriptionLimit, 0},
+		{"too many fields", manyFields(30, "value"), 0, 0, embedFieldsLimit},
+		{"fields over the total", manyFields(10, long(1000)), 0, 0, 5},
+		{"fields dropped before the description", manyFields(5, long(1000)).Description(long(4000)), 0, 4000, 1},
+		{"description over the total", NewEmbed("", StyleInfo).Title(long(256)).Footer(long(2048), "").Description(long(4000)), 256, embedTotalLimit - 256 - 2048, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := tt.embed.Build()
+			if got := utf8.RuneCountInString(embed.Title); got != tt.wantTitle {
+				t.Errorf("title is %v characters, want %v", got, tt.wantTitle)
+			}
+			if got := utf8.RuneCountInString(embed.Description); got != tt.wantDescription {
+				t.Errorf("description is %v characters, want %v", got, tt.wantDescription)
+			}
+			if len(embed.Fields) != tt.wantFields {
+				t.Errorf("embed has %v fields, want %v", len(embed.Fields), tt.wantFields)
+			}
+			for _, field := range embed.Fields {
+				if n := utf8.RuneCountInString(field.Value); n > embedFieldValueLimit {
+					t.Errorf("field value is %v characters", n)
+				}
+			}
+			if total := embedLength(embed); total > embedTotalLimit {
+				t.Errorf("embed is %v characters in total", total)
+			}
+			if (&EmbedBuilder{embed: embed}).Validate() != nil {
+				t.Errorf("built embed doesn't validate: %v", (&EmbedBuilder{embed: embed}).Validate())
+			}
+		})
+	}
+}
+
+func TestEmbedBuilderBuildMarksTruncation(t *testing.T) {
+	embed := NewEmbed("", StyleInfo).Title(strings.Repeat("a", 300)).Build()
+	if !strings.HasSuffix(embed.Title, "…") {
+		t.Errorf("truncated title %q doesn't end with an ellipsis", embed.Title)
+	}
+}
+
+func TestEmbedBuilderBuildKeepsBuilder(t *testing.T) {
+	builder := NewEmbed("", StyleInfo).Field("name", strings.Repeat("a", 2000), false)
+	builder.Build()
+	if got := len(builder.embed.Fields[0].Value); got != 2000 {
+		t.Errorf("Build() modified the builder, field value is %v characters", got)
+	}
+}
@@ -1,11 +1,9 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
-	"log"
-	"net/http"
-	"strconv"
 	"strings"
 )
 
@@ -32,26 +30,26 @@ var Errors = map[errorEnum]errorResponseType{
 // Deprecated: Use ErrorEdit instead.
 var ErrorHandler = Errors[ErrorResponse]
 
+// CheckAPIAlive probes apiHost once.
+// Deprecated: Use a HealthMonitor and HealthMonitor.Check instead, which don't block on the hot path.
 func CheckAPIAlive(apiHost string) bool {
-	resp, err := http.Get(apiHost)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		return false
-	}
-	return true
+	return NewHealthMonitor().request(apiHost).Up
 }
 
-const DeadAPI = "API is not running"
+// DeadAPI is returned by HealthMonitor.Check when a backend is down.
+var DeadAPI = BackendUnavailableError(CodeBackendUnavailable, "The API is not running. Please try again later.", nil)
 
 // errorFollowup [ErrorFollowup] sends an error message as a followup message with a deletion button.
 func errorFollowup(bot *discordgo.Session, i *discordgo.Interaction, errorContent ...any) {
 	embed, toPrint := errorEmbed(i, errorContent...)
 
-	logError(toPrint, i)
+	logError(toPrint, i, errorContent...)
 
-	_, _ = bot.FollowupMessageCreate(i, true, &discordgo.WebhookParams{
-		Content:    *sanitizeToken(&toPrint),
-		Components: []discordgo.MessageComponent{Components[DeleteButton]},
-		Embeds:     embed,
+	_, _ = followupCreate(bot, i, &discordgo.WebhookParams{
+		Content:         *sanitizeToken(&toPrint),
+		Components:      errorComponents(false, errorContent...),
+		Embeds:          embed,
+		AllowedMentions: noMentions(),
 	})
 }
 
@@ -59,15 +57,17 @@ func errorFollowup(bot *discordgo.Session, i *discordgo.Interaction, errorConten
 func ErrorEdit(bot *discordgo.Session, i *discordgo.Interaction, errorContent ...any) {
 	embed, toPrint := errorEmbed(i, errorContent...)
 
-	logError(toPrint, i)
+	logError(toPrint, i, errorContent...)
 
-	_, err := bot.InteractionResponseEdit(i, &discordgo.WebhookEdit{
-		Content:    sanitizeToken(&toPrint),
-		Components: &[]discordgo.MessageComponent{Components[DeleteButton]},
-		Embeds:     &embed,
+	components := errorComponents(false, errorContent...)
+	_, err := responseEditRetry(bot, i, &discordgo.WebhookEdit{
+		Content:         sanitizeToken(&toPrint),
+		Components:      &components,
+		Embeds:          &embed,
+		AllowedMentions: noMentions(),
 	})
 	if err != nil {
-		log.Printf("Error editing interaction for error (%v): %v", toPrint, err)
+		InteractionLogger(i).Error("Error editing interaction for error", "error", toPrint, "err", err)
 	}
 }
 
@@ -75,17 +75,19 @@ func ErrorEdit(bot *discordgo.Session, i *discordgo.Interaction, errorContent ..
 func ErrorEphemeralResponse(bot *discordgo.Session, i *discordgo.Interaction, errorContent ...any) {
 	embed, toPrint := errorEmbed(i, errorContent...)
 
-	logError(toPrint, i)
+	logError(toPrint, i, errorContent...)
 
-	_ = bot.InteractionRespond(i, &discordgo.InteractionResponse{
+	_ = respond(bot, i, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
 			// Note: this isn't documented, but you can use that if you want to.
 			// This flag just allows you to create messages visible only for the caller of the command
 			// (user who triggered the command)
-			Flags:   discordgo.MessageFlagsEphemeral,
-			Content: toPrint,
-			Embeds:  embed,
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         *sanitizeToken(&toPrint),
+			Embeds:          embed,
+			Components:      errorComponents(true, errorContent...),
+			AllowedMentions: noMentions(),
 		},
 	})
 }
@@ -93,12 +95,14 @@ func ErrorEphemeralResponse(bot *discordgo.Session, i *discordgo.Interaction, er
 func errorEphemeralFollowup(bot *discordgo.Session, i *discordgo.Interaction, errorContent ...any) {
 	embed, toPrint := errorEmbed(i, errorContent...)
 
-	logError(toPrint, i)
+	logError(toPrint, i, errorContent...)
 
-	_, _ = bot.FollowupMessageCreate(i, true, &discordgo.WebhookParams{
-		Flags:   discordgo.MessageFlagsEphemeral,
-		Content: *sanitizeToken(&toPrint),
-		Embeds:  embed,
+	_, _ = followupCreate(bot, i, &discordgo.WebhookParams{
+		Flags:           discordgo.MessageFlagsEphemeral,
+		Content:         *sanitizeToken(&toPrint),
+		Embeds:          embed,
+		Components:      errorComponents(true, errorContent...),
+		AllowedMentions: noMentions(),
 	})
 }
 
@@ -115,7 +119,8 @@ func formatError(errorContent ...any) string {
 		case []string:
 			errors = append(errors, content...)
 		case error:
-			errors = append(errors, content.Error())
+			// only the safe message is shown, the full error is logged by logError
+			errors = append(errors, AsError(content).Message)
 		case []any:
 			errors = append(errors, formatError(content...)) // Recursively format the error
 		//case any:
@@ -125,7 +130,8 @@ func formatError(errorContent ...any) string {
 		}
 	}
 
-	errorString := strings.Join(errors, "\n")
+	// errors often echo user input back, make sure it can't render as a mention
+	errorString := EscapeMentions(strings.Join(errors, "\n"))
 	if len(errors) > 1 {
 		errorString = "Multiple errors have occurred:\n" + errorString
 	}
@@ -136,35 +142,35 @@ func formatError(errorContent ...any) string {
 func errorEmbed(i *discordgo.Interaction, errorContent ...any) ([]*discordgo.MessageEmbed, string) {
 	errorString := formatError(errorContent)
 
-	// decode ED4245 to int
-	color, _ := strconv.ParseInt("ED4245", 16, 64)
-
-	embed := []*discordgo.MessageEmbed{
-		{
-			Type: discordgo.EmbedTypeRich,
-			Fields: []*discordgo.MessageEmbedField{
-				{
-					Name:   "Error",
-					Value:  *sanitizeToken(&errorString),
-					Inline: false,
-				},
-			},
-			Color: int(color),
-		},
+	code := errorCode(errorContent...)
+	var footer []string
+	if code != "" {
+		footer = append(footer, "Code: "+code)
+	}
+	// only internal and backend errors need looking into, the user can fix the others themselves
+	if isIncident(errorContent...) {
+		footer = append(footer, "Incident: "+newIncident(i, errorContent...).ID)
 	}
+	builder := NewEmbed(i.GuildID, StyleError).Field("Error", *sanitizeToken(&errorString), false)
+	if len(footer) > 0 {
+		builder.Footer(strings.Join(footer, " • "), "")
+	}
+	embed := []*discordgo.MessageEmbed{builder.Build()}
 
 	var toPrint string
 
 	switch i.Type {
 	case discordgo.InteractionApplicationCommand:
 		toPrint = fmt.Sprintf(
-			"Could not run the [command](https://github.com/ellypaws/sd-discord-bot) `%v`",
-			i.ApplicationCommandData().Name,
+			"Could not run the %v %v",
+			helpLink("command", code),
+			InlineCode(i.ApplicationCommandData().Name),
 		)
 	case discordgo.InteractionMessageComponent:
 		toPrint = fmt.Sprintf(
-			"Could not run the [button](https://github.com/ellypaws/sd-discord-bot) `%v`",
-			i.MessageComponentData().CustomID,
+			"Could not run the %v %v",
+			helpLink("button", code),
+			InlineCode(i.MessageComponentData().CustomID),
 		)
 		if i.Message != nil {
 			toPrint += fmt.Sprintf(" on message https://discord.com/channels/%v/%v/%v", i.GuildID, i.ChannelID, i.Message.ID)
@@ -173,27 +179,114 @@ func errorEmbed(i *discordgo.Interaction, errorContent ...any) ([]*discordgo.Mes
 	return embed, toPrint
 }
 
+// helpLink links the text to the help page of the error code, if it has one.
+func helpLink(text, code string) string {
+	if code == "" {
+		code = CodeInternal
+	}
+	if link := HelpURL(code); link != "" {
+		return fmt.Sprintf("[%v](<%v>)", text, link)
+	}
+	return text
+}
+
+// DismissMessage deletes the message the component is on. Unlike the channel API,
+// this also works for ephemeral messages, which is what error responses usually are.
+func DismissMessage(bot *discordgo.Session, i *discordgo.Interaction) error {
+	err := respond(bot, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		return err
+	}
+	return bot.InteractionResponseDelete(i)
+}
+
+// sanitizeToken redacts the bot token and any other secrets from the string, see Redact.
 func sanitizeToken(errorString *string) *string {
 	if errorString == nil {
 		return errorString
 	}
-	if Token == nil {
-		log.Println("WARNING: Token is nil")
-		return errorString
+	sanitizedString := Redact(*errorString)
+	return &sanitizedString
+}
+
+// errorCode returns the code of the first *Error in errorContent.
+func errorCode(errorContent ...any) string {
+	for _, content := range errorContent {
+		switch content := content.(type) {
+		case error:
+			return AsError(content).Code
+		case []any:
+			if code := errorCode(content...); code != "" {
+				return code
+			}
+		}
 	}
-	if strings.Contains(*errorString, *Token) {
-		log.Printf("WARNING: Bot token was found in the error message. Replacing it with \"Bot Token\": %v", *errorString)
-		sanitizedString := strings.ReplaceAll(*errorString, *Token, "[TOKEN]")
-		errorString = &sanitizedString
+	return ""
+}
+
+// errorChain returns the full text of every error in errorContent, including the wrapped internal details.
+func errorChain(errorContent ...any) []string {
+	var chain []string
+	for _, content := range errorContent {
+		switch content := content.(type) {
+		case error:
+			chain = append(chain, content.Error())
+		case []any:
+			chain = append(chain, errorChain(content...)...)
+		}
 	}
-	return errorString
+	return chain
 }
 
-func logError(errorString string, i *discordgo.Interaction) {
-	log.Printf("ERROR: %v", errorString)
-	if i == nil || i.Member == nil {
-		log.Printf("WARNING: Member is nil!")
+func logError(errorString string, i *discordgo.Interaction, errorContent ...any) {
+	chain := errorChain(errorContent...)
+	for n := range chain {
+		chain[n] = *sanitizeToken(&chain[n])
+	}
+	InteractionLogger(i).Error(errorString, "errors", chain)
+}
+
+// RespondError renders err for the interaction, as an ephemeral response if the interaction
+// hasn't been responded to yet, or as an ephemeral followup otherwise. Errors wrapping ErrHandled were already
+// shown to the user and are skipped.
+func RespondError(bot *discordgo.Session, i *discordgo.Interaction, err error) {
+	if errors.Is(err, ErrHandled) {
+		InteractionLogger(i).Debug("Error already reported to user", "err", err)
+		return
+	}
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		logError("autocomplete failed", i, err)
+		return
+	}
+
+	embed, toPrint := errorEmbed(i, err)
+	logError(toPrint, i, err)
+	components := errorComponents(true, err)
+
+	respondErr := respond(bot, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags:           discordgo.MessageFlagsEphemeral,
+			Content:         *sanitizeToken(&toPrint),
+			Embeds:          embed,
+			Components:      components,
+			AllowedMentions: noMentions(),
+		},
+	})
+	if respondErr == nil {
 		return
 	}
-	log.Printf("User: %v", i.Member.User.Username)
+
+	_, followupErr := followupCreate(bot, i, &discordgo.WebhookParams{
+		Flags:           discordgo.MessageFlagsEphemeral,
+		Content:         *sanitizeToken(&toPrint),
+		Embeds:          embed,
+		Components:      components,
+		AllowedMentions: noMentions(),
+	})
+	if followupErr != nil {
+		InteractionLogger(i).Error("Error reporting error to user", "respond_err", respondErr, "err", followupErr)
+	}
 }
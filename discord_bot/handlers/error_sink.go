@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// ErrorSink receives a detailed report of every incident.
+type ErrorSink interface {
+	Report(ctx context.Context, embed *discordgo.MessageEmbed) error
+}
+
+// ChannelSink posts incident reports to a Discord channel, usually a private admin channel.
+type ChannelSink struct {
+	Bot       *discordgo.Session
+	ChannelID string
+}
+
+func (s ChannelSink) Report(ctx context.Context, embed *discordgo.MessageEmbed) error {
+	_, err := s.Bot.ChannelMessageSendComplex(s.ChannelID, &discordgo.MessageSend{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: noMentions(),
+	}, discordgo.WithContext(ctx))
+	return err
+}
+
+// WebhookSink posts incident reports to a webhook URL. The URL may point at any HTTP server accepting
+// Discord's execute webhook payload, such as a local stand-in during tests.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s WebhookSink) Report(ctx context.Context, embed *discordgo.MessageEmbed) error {
+	payload, err := json.Marshal(discordgo.WebhookParams{
+		Embeds:          []*discordgo.MessageEmbed{embed},
+		AllowedMentions: noMentions(),
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := s.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second, Transport: RedactingTransport(nil)}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error webhook responded with %v", resp.Status)
+	}
+	return nil
+}
+
+// ErrorReporter forwards incidents to an ErrorSink, suppressing duplicates and limiting the rate of reports
+// so that an outage doesn't flood the sink. Suppressed incidents are counted in the next report that goes out.
+type ErrorReporter struct {
+	sink ErrorSink
+	// DedupeWindow is how long identical incidents are suppressed after one was reported.
+	DedupeWindow time.Duration
+	// Burst is how many reports can be sent at once, refilled at one report per Refill.
+	Burst  int
+	Refill time.Duration
+
+	mu         sync.Mutex
+	tokens     float64
+	lastRefill time.Time
+	seen       map[string]time.Time
+	suppressed int
+}
+
+// NewErrorReporter returns an ErrorReporter allowing bursts of 5 reports, then one per minute,
+// and suppressing identical incidents for 10 minutes.
+func NewErrorReporter(sink ErrorSink) *ErrorReporter {
+	return &ErrorReporter{
+		sink:         sink,
+		DedupeWindow: 10 * time.Minute,
+		Burst:        5,
+		Refill:       time.Minute,
+		tokens:       5,
+		lastRefill:   time.Now(),
+		seen:         make(map[string]time.Time),
+	}
+}
+
+var (
+	errorReporter   *ErrorReporter
+	errorReporterMu sync.RWMutex
+)
+
+// SetErrorReporter sets where incidents are forwarded. nil disables forwarding.
+func SetErrorReporter(r *ErrorReporter) {
+	errorReporterMu.Lock()
+	defer errorReporterMu.Unlock()
+	errorReporter = r
+}
+
+// CurrentErrorReporter returns the ErrorReporter set with SetErrorReporter, or nil.
+func CurrentErrorReporter() *ErrorReporter {
+	errorReporterMu.RLock()
+	defer errorReporterMu.RUnlock()
+	return errorReporter
+}
+
+// reportIncident forwards the incident in the background if an ErrorReporter is set.
+func reportIncident(incident *Incident) {
+	r := CurrentErrorReporter()
+	if r == nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := r.Report(ctx, incident); err != nil {
+			Logger().Error("Error forwarding incident", "incident", incident.ID, "err", err)
+		}
+	}()
+}
+
+// Report sends the incident to the sink unless it is a duplicate or the rate limit is exhausted.
+func (r *ErrorReporter) Report(ctx context.Context, incident *Incident) error {
+	suppressed, ok := r.allow(incident)
+	if !ok {
+		return nil
+	}
+
+	embed := IncidentEmbed(incident.GuildID, incident)
+	if suppressed > 0 {
+		embed.Field("Suppressed", fmt.Sprintf("%d similar or rate limited incidents were not reported", suppressed), false)
+	}
+	return r.sink.Report(ctx, embed.Build())
+}
+
+// Notify sends the embed to the sink, skipping deduplication but not the rate limit.
+// Use it for events that are already rare, such as a backend going down.
+func (r *ErrorReporter) Notify(ctx context.Context, embed *discordgo.MessageEmbed) error {
+	r.mu.Lock()
+	ok := r.take(time.Now())
+	r.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return r.sink.Report(ctx, embed)
+}
+
+func (r *ErrorReporter) allow(incident *Incident) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for key, at := range r.seen {
+		if now.Sub(at) > r.DedupeWindow {
+			delete(r.seen, key)
+		}
+	}
+
+	key := fingerprint(incident)
+	if _, ok := r.seen[key]; ok {
+		r.suppressed++
+		return 0, false
+	}
+
+	if !r.take(now) {
+		return 0, false
+	}
+
+	r.seen[key] = now
+	suppressed := r.suppressed
+	r.suppressed = 0
+	return suppressed, true
+}
+
+// take takes a token from the rate limit, counting the report as suppressed if there is none. r.mu must be held.
+func (r *ErrorReporter) take(now time.Time) bool {
+	if r.Refill > 0 {
+		r.tokens = min(float64(r.Burst), r.tokens+float64(now.Sub(r.lastRefill))/float64(r.Refill))
+	}
+	r.lastRefill = now
+	if r.tokens < 1 {
+		r.suppressed++
+		return false
+	}
+	r.tokens--
+	return true
+}
+
+// fingerprint identifies incidents caused by the same problem.
+func fingerprint(incident *Incident) string {
+	var first string
+	if len(incident.Errors) > 0 {
+		first, _, _ = strings.Cut(incident.Errors[0], "\n")
+	}
+	return incident.Code + "|" + incident.Command + "|" + first
+}
@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// webhookServer records the payloads posted to it.
+type webhookServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	payloads []discordgo.WebhookParams
+}
+
+func newWebhookServer(t *testing.T) *webhookServer {
+	s := &webhookServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload discordgo.WebhookParams
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.payloads = append(s.payloads, payload)
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []discordgo.WebhookParams {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.payloads
+}
+
+func incident(id, code, err string) *Incident {
+	return &Incident{ID: id, Time: time.Now(), Code: code, Command: "imagine", Errors: []string{err}}
+}
+
+func TestErrorReporterDedupesIncidents(t *testing.T) {
+	server := newWebhookServer(t)
+	r := NewErrorReporter(WebhookSink{URL: server.URL})
+	ctx := context.Background()
+
+	for _, inc := range []*Incident{
+		incident("1", "internal", "connection reset\ndetails"),
+		incident("2", "internal", "connection reset\nother details"),
+		incident("3", "internal", "connection reset"),
+		incident("4", "internal", "disk full"),
+	} {
+		if err := r.Report(ctx, inc); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	payloads := server.received()
+	if len(payloads) != 2 {
+		t.Fatalf("received %v reports, want 2", len(payloads))
+	}
+	last := payloads[1].Embeds[0]
+	var suppressed string
+	for _, field := range last.Fields {
+		if field.Name == "Suppressed" {
+			suppressed = field.Value
+		}
+	}
+	if !strings.HasPrefix(suppressed, "2 ") {
+		t.Errorf("suppressed field = %q, want the 2 duplicates counted", suppressed)
+	}
+	if payloads[0].AllowedMentions == nil || len(payloads[0].AllowedMentions.Parse) != 0 {
+		t.Errorf("allowed mentions = %+v, want none", payloads[0].AllowedMentions)
+	}
+}
+
+func TestErrorReporterDedupeWindowExpires(t *testing.T) {
+	server := newWebhookServer(t)
+	r := NewErrorReporter(WebhookSink{URL: server.URL})
+	r.DedupeWindow = 10 * time.Millisecond
+	ctx := context.Background()
+
+	_ = r.Report(ctx, incident("1", "internal", "connection reset"))
+	time.Sleep(20 * time.Millisecond)
+	_ = r.Report(ctx, incident("2", "internal", "connection reset"))
+
+	if got := len(server.received()); got != 2 {
+		t.Errorf("received %v reports, want the duplicate to be reported again after the window", got)
+	}
+}
+
+func TestErrorReporterRateLimits(t *testing.T) {
+	server := newWebhookServer(t)
+	r := NewErrorReporter(WebhookSink{URL: server.URL})
+	r.Burst, r.tokens, r.Refill = 2, 2, time.Hour
+	ctx := context.Background()
+
+	for n, err := range []string{"a", "b", "c", "d"} {
+		if err := r.Report(ctx, incident(strconv.Itoa(n+1), "internal", err)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := r.Notify(ctx, &discordgo.MessageEmbed{Title: "api is down"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(server.received()); got != 2 {
+		t.Fatalf("received %v reports, want the burst of 2", got)
+	}
+
+	// a refilled token lets the next report through, counting everything that was dropped
+	r.mu.Lock()
+	r.tokens = 1
+	r.mu.Unlock()
+	if err := r.Report(ctx, incident("5", "internal", "e")); err != nil {
+		t.Fatal(err)
+	}
+	payloads := server.received()
+	if len(payloads) != 3 {
+		t.Fatalf("received %v reports, want 3", len(payloads))
+	}
+	fields := payloads[2].Embeds[0].Fields
+	if last := fields[len(fields)-1]; last.Name != "Suppressed" || !strings.HasPrefix(last.Value, "3 ") {
+		t.Errorf("last field = %+v, want the 3 rate limited reports counted", last)
+	}
+}
+
+func TestWebhookSinkReportsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unknown webhook", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	err := WebhookSink{URL: server.URL}.Report(context.Background(), &discordgo.MessageEmbed{})
+	if err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Report() = %v, want the 404 status", err)
+	}
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// Kinds of errors, use errors.Is to check what kind an error is:
+//
+//	if errors.Is(err, handlers.ErrPermission) { ... }
+var (
+	ErrUser               = errors.New("user error")
+	ErrPermission         = errors.New("permission denied")
+	ErrBackendUnavailable = errors.New("backend unavailable")
+	ErrInternal           = errors.New("internal error")
+)
+
+// ErrHandled marks an error that has already been shown to the user, the dispatcher doesn't render it again.
+// It is wrapped together with the original error, which can still be inspected with errors.Is and errors.As.
+var ErrHandled = errors.New("error already handled")
+
+// Stable error codes for errors that aren't created with a specific code.
+const (
+	CodeInternal           = "internal"
+	CodeBackendUnavailable = "backend_unavailable"
+	CodePermission         = "permission_denied"
+)
+
+const internalErrorMessage = "Something went wrong on our side. Please try again later."
+
+// Error is an error with a message that is safe to show to users.
+// The wrapped Err is only ever logged.
+type Error struct {
+	// Kind is one of ErrUser, ErrPermission, ErrBackendUnavailable or ErrInternal.
+	Kind error
+	// Code identifies the error, it must not change between releases.
+	Code string
+	// Message is shown to the user.
+	Message string
+	// Err is the underlying cause, for logs only.
+	Err error
+
+	// stack is where InternalError was called, recorded in the incident.
+	stack string
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%v [%v]: %v", e.Kind, e.Code, e.Message)
+	}
+	return fmt.Sprintf("%v [%v]: %v: %v", e.Kind, e.Code, e.Message, e.Err)
+}
+
+// Is lets an *Error without a cause, such as DeadAPI, match the errors with the same kind, code and message
+// whatever their cause, so that errors.Is(err, DeadAPI) holds for the errors returned by HealthMonitor.Check.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Err == nil && t.Kind == e.Kind && t.Code == e.Code && t.Message == e.Message
+}
+
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// UserError is caused by the user, for example an invalid option. The message should tell them how to fix it.
+func UserError(code, message string) *Error {
+	return &Error{Kind: ErrUser, Code: code, Message: message}
+}
+
+// PermissionError is returned when the user isn't allowed to do something.
+func PermissionError(code, message string) *Error {
+	if code == "" {
+		code = CodePermission
+	}
+	return &Error{Kind: ErrPermission, Code: code, Message: message}
+}
+
+// BackendUnavailableError is returned when a service the bot depends on can't be reached.
+func BackendUnavailableError(code, message string, err error) *Error {
+	if code == "" {
+		code = CodeBackendUnavailable
+	}
+	if message == "" {
+		message = "A service the bot depends on is unavailable. Please try again later."
+	}
+	return &Error{Kind: ErrBackendUnavailable, Code: code, Message: message, Err: err}
+}
+
+// InternalError wraps an unexpected error. Users only see a generic message.
+// The stack of the caller is kept for the incident report.
+func InternalError(code string, err error) *Error {
+	if code == "" {
+		code = CodeInternal
+	}
+	return &Error{Kind: ErrInternal, Code: code, Message: internalErrorMessage, Err: err, stack: string(debug.Stack())}
+}
+
+// AsError returns err as an *Error. A *ModalValidationError becomes a user error listing its problems,
+// any other error is wrapped as an internal error. The stack of where that error came from is lost by then,
+// so none is recorded.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	var invalid *ModalValidationError
+	if errors.As(err, &invalid) {
+		return invalid.userError()
+	}
+	return &Error{Kind: ErrInternal, Code: CodeInternal, Message: internalErrorMessage, Err: err}
+}
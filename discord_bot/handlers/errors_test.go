@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAsError(t *testing.T) {
+	invalid := &ModalValidationError{Problems: []string{"Prompt is required", "Steps must be a whole number"}}
+	user := UserError("cooldown", "Slow down.")
+
+	tests := []struct {
+		name        string
+		err         error
+		wantKind    error
+		wantCode    string
+		wantMessage string
+	}{
+		{"typed", user, ErrUser, "cooldown", "Slow down."},
+		{"wrapped typed", fmt.Errorf("handling: %w", user), ErrUser, "cooldown", "Slow down."},
+		{"modal validation", invalid, ErrUser, "invalid_input", "Prompt is required\nSteps must be a whole number"},
+		{"wrapped modal validation", fmt.Errorf("decoding: %w", invalid), ErrUser, "invalid_input", "Prompt is required\nSteps must be a whole number"},
+		{"plain", errors.New("connection reset"), ErrInternal, CodeInternal, internalErrorMessage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AsError(tt.err)
+			if got.Kind != tt.wantKind || got.Code != tt.wantCode || got.Message != tt.wantMessage {
+				t.Errorf("AsError() = %v %q %q, want %v %q %q", got.Kind, got.Code, got.Message, tt.wantKind, tt.wantCode, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestAsErrorKeepsModalValidationError(t *testing.T) {
+	var invalid *ModalValidationError
+	if !errors.As(AsError(&ModalValidationError{Problems: []string{"x"}}), &invalid) {
+		t.Error("AsError() doesn't wrap the *ModalValidationError")
+	}
+}
+
+func TestFormatErrorShowsModalProblems(t *testing.T) {
+	got := formatError(&ModalValidationError{Problems: []string{"Prompt is required"}})
+	if got != "Prompt is required" {
+		t.Errorf("formatError() = %q, want the validation problem", got)
+	}
+}
+
+func TestRespondErrorRecordsIncidents(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		incident  bool
+		withStack bool
+	}{
+		{"user", UserError("cooldown", "Slow down."), false, false},
+		{"permission", PermissionError("", "Only admins can do that."), false, false},
+		{"modal validation", &ModalValidationError{Problems: []string{"Prompt is required"}}, false, false},
+		{"backend", BackendUnavailableError("", "", errors.New("connection refused")), true, false},
+		{"internal", InternalError("", errors.New("nil map")), true, true},
+		{"plain", errors.New("nil map"), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, session := newFakeDiscord(t)
+			before := incidentCount()
+			RespondError(session, testInteraction(time.Now()), tt.err)
+
+			if created := incidentCount() - before; created != map[bool]int{false: 0, true: 1}[tt.incident] {
+				t.Fatalf("created %v incidents, want an incident: %v", created, tt.incident)
+			}
+			requests := fake.received()
+			if len(requests) != 1 {
+				t.Fatalf("sent %v requests, want one response", len(requests))
+			}
+			if got := strings.Contains(requests[0].Body, "Incident: "); got != tt.incident {
+				t.Errorf("the response shows an incident ID: %v, want %v", got, tt.incident)
+			}
+			if !tt.incident {
+				return
+			}
+
+			Incidents.mu.RLock()
+			incident := Incidents.byID[Incidents.order[len(Incidents.order)-1]]
+			Incidents.mu.RUnlock()
+			// the stack is where the error was built, not where it was rendered
+			if got := strings.Contains(incident.Stack, "TestRespondErrorRecordsIncidents"); got != tt.withStack {
+				t.Errorf("the incident has the test's stack: %v, want %v\n%v", got, tt.withStack, incident.Stack)
+			}
+			if strings.Contains(incident.Stack, "handlers.RespondError(") {
+				t.Errorf("the incident has the stack of the renderer:\n%v", incident.Stack)
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// fakeRequest is a request received by fakeDiscord.
+type fakeRequest struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fakeDiscord stands in for the Discord REST API. It records every request and answers with respond,
+// which defaults to echoing a message for every message endpoint and 204 for everything else.
+type fakeDiscord struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []fakeRequest
+	respond  func(r fakeRequest) (status int, body any)
+}
+
+// newFakeDiscord returns the fake API and a session sending every request to it.
+func newFakeDiscord(t *testing.T) (*fakeDiscord, *discordgo.Session) {
+	t.Helper()
+	f := &fakeDiscord{}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.Close)
+
+	target, _ := url.Parse(f.URL)
+	session, err := discordgo.New("Bot " + "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Client = &http.Client{Transport: rewriteHost{target: target}}
+	session.State.User = &discordgo.User{ID: "1000"}
+	return f, session
+}
+
+func (f *fakeDiscord) serve(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := fakeRequest{Method: r.Method, Path: strings.TrimPrefix(r.URL.Path, "/api/v"+discordgo.APIVersion), Body: string(body)}
+
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	respond := f.respond
+	f.mu.Unlock()
+
+	status, response := http.StatusNoContent, any(nil)
+	if respond != nil {
+		status, response = respond(req)
+	} else if strings.Contains(req.Path, "/messages/") && r.Method != http.MethodDelete {
+		status, response = http.StatusOK, fakeMessage(req)
+	}
+
+	if response == nil {
+		w.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+// setRespond replaces how requests are answered.
+func (f *fakeDiscord) setRespond(respond func(r fakeRequest) (int, any)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.respond = respond
+}
+
+// received returns the requests received so far.
+func (f *fakeDiscord) received() []fakeRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeRequest(nil), f.requests...)
+}
+
+// fakeMessage is the message a message endpoint answers with: the message in the path, with the content
+// and components of the request.
+func fakeMessage(r fakeRequest) *discordgo.Message {
+	msg := &discordgo.Message{}
+	_ = json.Unmarshal([]byte(r.Body), msg)
+	parts := strings.Split(r.Path, "/")
+	msg.ID = parts[len(parts)-1]
+	if msg.ID == "@original" {
+		msg.ID = "2000"
+	}
+	msg.ChannelID = "3000"
+	return msg
+}
+
+type rewriteHost struct {
+	target *url.URL
+}
+
+func (t rewriteHost) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host, req.Host = t.target.Scheme, t.target.Host, t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// snowflakeAt returns an ID created at t, interactions with old IDs have expired tokens.
+func snowflakeAt(t time.Time) string {
+	return strconv.FormatInt((t.UnixMilli()-1420070400000)<<22, 10)
+}
+
+// testInteraction returns a command interaction from user 4000 created at created.
+func testInteraction(created time.Time) *discordgo.Interaction {
+	return &discordgo.Interaction{
+		ID:        snowflakeAt(created),
+		AppID:     "1000",
+		Token:     "interaction-token",
+		Type:      discordgo.InteractionApplicationCommand,
+		ChannelID: "3000",
+		GuildID:   "5000",
+		Member:    &discordgo.Member{User: &discordgo.User{ID: "4000"}},
+		Data:      discordgo.ApplicationCommandInteractionData{Name: "test"},
+	}
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// interactionTokenLifetime is how long Discord accepts an interaction token for followups and edits.
+// A small margin is kept so that requests sent right before the expiry don't fail in flight.
+const interactionTokenLifetime = 15*time.Minute - 30*time.Second
+
+// followupRetention is how long followups are tracked after the interaction was created.
+const followupRetention = 24 * time.Hour
+
+// Followup is a followup message sent for an interaction.
+// Handles stay valid after the interaction token expires, edits then go through the channel API.
+type Followup struct {
+	ID        string
+	ChannelID string
+
+	// guarded by followupsMu, they change with edits and deletes
+	content    string
+	components []discordgo.MessageComponent
+	index      int
+
+	interaction *discordgo.Interaction
+}
+
+// Content returns the content of the followup as of its last edit.
+func (f *Followup) Content() string {
+	followupsMu.Lock()
+	defer followupsMu.Unlock()
+	return f.content
+}
+
+// Components returns the components of the followup as of its last edit.
+func (f *Followup) Components() []discordgo.MessageComponent {
+	followupsMu.Lock()
+	defer followupsMu.Unlock()
+	return f.components
+}
+
+// Index returns the position of the followup among the followups of the interaction, starting at 0.
+func (f *Followup) Index() int {
+	followupsMu.Lock()
+	defer followupsMu.Unlock()
+	return f.index
+}
+
+type trackedFollowups struct {
+	created   time.Time
+	followups []*Followup
+}
+
+var (
+	followups   = make(map[string]*trackedFollowups)
+	followupsMu sync.Mutex
+)
+
+// TrackFollowup records msg as a followup of the interaction and returns its handle.
+func TrackFollowup(i *discordgo.Interaction, msg *discordgo.Message) *Followup {
+	if i == nil || msg == nil {
+		return nil
+	}
+
+	followupsMu.Lock()
+	defer followupsMu.Unlock()
+
+	pruneFollowups()
+
+	tracked, ok := followups[i.ID]
+	if !ok {
+		tracked = &trackedFollowups{created: interactionCreated(i)}
+		followups[i.ID] = tracked
+	}
+	f := &Followup{
+		ID:          msg.ID,
+		ChannelID:   msg.ChannelID,
+		content:     msg.Content,
+		components:  msg.Components,
+		index:       len(tracked.followups),
+		interaction: i,
+	}
+	tracked.followups = append(tracked.followups, f)
+	return f
+}
+
+// Followups returns the followups sent for the interaction in the order they were created.
+func Followups(i *discordgo.Interaction) []*Followup {
+	followupsMu.Lock()
+	defer followupsMu.Unlock()
+	tracked, ok := followups[i.ID]
+	if !ok {
+		return nil
+	}
+	return append([]*Followup(nil), tracked.followups...)
+}
+
+// FollowupAt returns the nth followup sent for the interaction, starting at 0.
+func FollowupAt(i *discordgo.Interaction, n int) (*Followup, bool) {
+	all := Followups(i)
+	if n < 0 || n >= len(all) {
+		return nil, false
+	}
+	return all[n], true
+}
+
+// Edit edits the followup with the same content types accepted by the response helpers.
+func (f *Followup) Edit(bot *discordgo.Session, content ...any) (*discordgo.Message, error) {
+	webhookEdit := webhookFromContents(content...)
+	contentEdit(webhookEdit, content...)
+	if err := checkAttachments(bot, f.interaction.GuildID, webhookEdit.Files); err != nil {
+		return nil, err
+	}
+
+	msg, err := editMessageByID(bot, f.interaction, f.ChannelID, f.ID, webhookEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	followupsMu.Lock()
+	f.content = msg.Content
+	f.components = msg.Components
+	followupsMu.Unlock()
+	return msg, nil
+}
+
+// Delete deletes the followup and stops tracking it.
+// Once the interaction token has expired, the message is deleted through the channel API instead.
+func (f *Followup) Delete(bot *discordgo.Session) error {
+	i, op := f.interaction, "followup_delete"
+	del := func(options ...discordgo.RequestOption) error {
+		return bot.FollowupMessageDelete(f.interaction, f.ID, options...)
+	}
+	if tokenExpired(f.interaction) {
+		// the channel API doesn't use the token, so it isn't bound to the interaction's deadline
+		i, op = nil, "channel_message_delete"
+		del = func(options ...discordgo.RequestOption) error {
+			return bot.ChannelMessageDelete(f.ChannelID, f.ID, options...)
+		}
+	}
+
+	attempts := 0
+	_, err := retryIdempotent(i, op, nil, func(options ...discordgo.RequestOption) (struct{}, error) {
+		attempts++
+		err := del(options...)
+		// an earlier attempt went through even though it failed
+		if attempts > 1 && unknownMessage(err) {
+			err = nil
+		}
+		return struct{}{}, err
+	})
+	if err != nil {
+		return err
+	}
+
+	followupsMu.Lock()
+	defer followupsMu.Unlock()
+	if tracked, ok := followups[f.interaction.ID]; ok {
+		for n, tf := range tracked.followups {
+			if tf == f {
+				tracked.followups = append(tracked.followups[:n], tracked.followups[n+1:]...)
+				break
+			}
+		}
+		for n, tf := range tracked.followups {
+			tf.index = n
+		}
+	}
+	return nil
+}
+
+// editMessageByID edits a message sent for the interaction.
+// Once the interaction token has expired, the message is edited through the channel API instead.
+func editMessageByID(bot *discordgo.Session, i *discordgo.Interaction, channelID, messageID string, webhookEdit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	if !tokenExpired(i) {
+		return retryIdempotent(i, "followup_edit", webhookEdit.Files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+			return bot.FollowupMessageEdit(i, messageID, webhookEdit, options...)
+		})
+	}
+
+	if channelID == "" {
+		channelID = i.ChannelID
+	}
+	if channelID == "" {
+		return nil, errors.New("interaction token has expired and the channel of the message is unknown")
+	}
+
+	edit := &discordgo.MessageEdit{
+		ID:              messageID,
+		Channel:         channelID,
+		Content:         webhookEdit.Content,
+		Files:           webhookEdit.Files,
+		Attachments:     webhookEdit.Attachments,
+		AllowedMentions: webhookEdit.AllowedMentions,
+	}
+	// MessageEdit always sends components and embeds, so keep the current ones unless they're being replaced
+	if webhookEdit.Components == nil || webhookEdit.Embeds == nil {
+		current, err := retryIdempotent(nil, "channel_message", nil, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+			return bot.ChannelMessage(channelID, messageID, options...)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("interaction token has expired and the message could not be fetched: %w", err)
+		}
+		edit.Components = current.Components
+		edit.Embeds = current.Embeds
+	}
+	if webhookEdit.Components != nil {
+		edit.Components = *webhookEdit.Components
+	}
+	if webhookEdit.Embeds != nil {
+		edit.Embeds = *webhookEdit.Embeds
+	}
+	msg, err := retryIdempotent(nil, "channel_message_edit", edit.Files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return bot.ChannelMessageEditComplex(edit, options...)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("interaction token has expired and editing through the channel failed: %w", err)
+	}
+	return msg, nil
+}
+
+// unknownMessage reports whether err is Discord saying the message doesn't exist.
+func unknownMessage(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == discordgo.ErrCodeUnknownMessage
+}
+
+// interactionCreated returns when the interaction was created, based on its snowflake ID.
+func interactionCreated(i *discordgo.Interaction) time.Time {
+	created, err := discordgo.SnowflakeTimestamp(i.ID)
+	if err != nil {
+		return time.Now()
+	}
+	return created
+}
+
+func tokenExpired(i *discordgo.Interaction) bool {
+	return time.Since(interactionCreated(i)) > interactionTokenLifetime
+}
+
+// pruneFollowups stops tracking followups of old interactions. followupsMu must be held.
+func pruneFollowups() {
+	for id, tracked := range followups {
+		if time.Since(tracked.created) > followupRetention {
+			delete(followups, id)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// trackThree tracks three followups of the interaction, with IDs 7001 to 7003.
+func trackThree(i *discordgo.Interaction) {
+	for _, id := range []string{"7001", "7002", "7003"} {
+		TrackFollowup(i, &discordgo.Message{ID: id, ChannelID: "3000", Content: "followup " + id})
+	}
+}
+
+// followupIDs returns the IDs and indexes of the followups of the interaction.
+func followupIDs(i *discordgo.Interaction) (ids []string, indexes []int) {
+	for _, f := range Followups(i) {
+		ids = append(ids, f.ID)
+		indexes = append(indexes, f.Index())
+	}
+	return ids, indexes
+}
+
+func TestFollowupTracking(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	i := testInteraction(time.Now())
+	trackThree(i)
+
+	ids, indexes := followupIDs(i)
+	if !slices.Equal(ids, []string{"7001", "7002", "7003"}) || !slices.Equal(indexes, []int{0, 1, 2}) {
+		t.Fatalf("tracked %v at %v, want 7001 to 7003 in order", ids, indexes)
+	}
+	if f, ok := FollowupAt(i, 1); !ok || f.ID != "7002" || f.Content() != "followup 7002" {
+		t.Errorf("FollowupAt(1) = %+v, %v, want 7002", f, ok)
+	}
+	for _, n := range []int{-1, 3} {
+		if _, ok := FollowupAt(i, n); ok {
+			t.Errorf("FollowupAt(%v) found a followup", n)
+		}
+	}
+	if got := Followups(testInteraction(time.Now().Add(time.Second))); got != nil {
+		t.Errorf("an untracked interaction has followups %v", got)
+	}
+
+	second, _ := FollowupAt(i, 1)
+	if err := second.Delete(session); err != nil {
+		t.Fatal(err)
+	}
+	requests := fake.received()
+	if last := requests[len(requests)-1]; last.Method != http.MethodDelete || last.Path != "/webhooks/1000/interaction-token/messages/7002" {
+		t.Errorf("delete sent %v %v, want it through the interaction token", last.Method, last.Path)
+	}
+	ids, indexes = followupIDs(i)
+	if !slices.Equal(ids, []string{"7001", "7003"}) || !slices.Equal(indexes, []int{0, 1}) {
+		t.Errorf("after deleting 7002 tracked %v at %v, want 7001 and 7003 renumbered", ids, indexes)
+	}
+}
+
+func TestFollowupEdit(t *testing.T) {
+	tests := []struct {
+		name    string
+		created time.Time
+		want    []string
+	}{
+		{
+			name:    "token valid",
+			created: time.Now(),
+			want:    []string{"PATCH /webhooks/1000/interaction-token/messages/7001"},
+		},
+		{
+			name:    "token expired",
+			created: time.Now().Add(-time.Hour),
+			want:    []string{"GET /channels/3000/messages/7001", "PATCH /channels/3000/messages/7001"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake, session := newFakeDiscord(t)
+			i := testInteraction(tt.created)
+			trackThree(i)
+			f, _ := FollowupAt(i, 0)
+
+			if _, err := f.Edit(session, "edited"); err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, r := range fake.received() {
+				got = append(got, r.Method+" "+r.Path)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("sent %v, want %v", got, tt.want)
+			}
+			if f.Content() != "edited" {
+				t.Errorf("Content() = %q after the edit", f.Content())
+			}
+		})
+	}
+}
+
+func TestFollowupConcurrentAccess(t *testing.T) {
+	_, session := newFakeDiscord(t)
+	i := testInteraction(time.Now())
+	trackThree(i)
+	f, _ := FollowupAt(i, 2)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, _ = f.Edit(session, "edited")
+		}()
+		go func() {
+			defer wg.Done()
+			_, _, _ = f.Content(), f.Components(), f.Index()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFollowupDeleteAfterTokenExpired(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	i := testInteraction(time.Now().Add(-time.Hour))
+	trackThree(i)
+	f, _ := FollowupAt(i, 1)
+
+	// the first attempt fails after deleting the message, the retry finds it gone
+	var attempts int
+	fake.setRespond(func(r fakeRequest) (int, any) {
+		attempts++
+		if attempts == 1 {
+			return http.StatusInternalServerError, nil
+		}
+		return http.StatusNotFound, map[string]any{"code": discordgo.ErrCodeUnknownMessage, "message": "Unknown Message"}
+	})
+
+	if err := f.Delete(session); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	for _, r := range fake.received() {
+		if r.Method != http.MethodDelete || r.Path != "/channels/3000/messages/7002" {
+			t.Errorf("sent %v %v, want the message deleted through the channel", r.Method, r.Path)
+		}
+	}
+	if attempts != 2 {
+		t.Errorf("sent %v requests, want a retry after the failure", attempts)
+	}
+	if ids, _ := followupIDs(i); !slices.Equal(ids, []string{"7001", "7003"}) {
+		t.Errorf("tracked %v after the delete, want 7002 gone", ids)
+	}
+}
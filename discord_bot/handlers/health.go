@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Defaults for NewHealthMonitor.
+const (
+	DefaultHealthInterval = 30 * time.Second
+	DefaultHealthTimeout  = 5 * time.Second
+	healthHistorySize     = 20
+)
+
+// Backend is a service the bot depends on. It is considered up while its URL responds with a 2xx status.
+type Backend struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// HealthCheck is the result of a single probe of a backend.
+type HealthCheck struct {
+	Time    time.Time
+	Up      bool
+	Latency time.Duration
+	Err     string
+}
+
+// BackendStatus is the cached state of a backend.
+type BackendStatus struct {
+	Backend
+	// Checked is false until the first probe has finished, the backend is assumed to be up until then.
+	Checked bool
+	Up      bool
+	// Since is when the backend last changed between up and down.
+	Since   time.Time
+	Latency time.Duration
+	// LastError is the error of the most recent failed probe, even if the backend has recovered since.
+	LastError   string
+	LastChecked time.Time
+	// History holds the most recent probes, oldest first.
+	History []HealthCheck
+}
+
+// HealthMonitor polls backends in the background and caches their status,
+// so that handlers can check a backend without making a request.
+type HealthMonitor struct {
+	Interval time.Duration
+	Timeout  time.Duration
+	client   *http.Client
+
+	mu          sync.RWMutex
+	statuses    map[string]*BackendStatus
+	transitions []func(BackendStatus)
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewHealthMonitor returns a monitor for the backends polling every DefaultHealthInterval.
+// Call Start to begin polling.
+func NewHealthMonitor(backends ...Backend) *HealthMonitor {
+	m := &HealthMonitor{
+		Interval: DefaultHealthInterval,
+		Timeout:  DefaultHealthTimeout,
+		client:   &http.Client{Transport: RedactingTransport(nil)},
+		statuses: make(map[string]*BackendStatus, len(backends)),
+	}
+	for _, backend := range backends {
+		m.statuses[backend.Name] = &BackendStatus{Backend: backend, Up: true, Since: time.Now()}
+	}
+	return m
+}
+
+// OnTransition registers fn to be called whenever a backend goes down or comes back up.
+func (m *HealthMonitor) OnTransition(fn func(BackendStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.transitions = append(m.transitions, fn)
+}
+
+// Start probes every backend right away and then every Interval until Stop is called.
+func (m *HealthMonitor) Start() {
+	m.mu.Lock()
+	if m.stop != nil {
+		m.mu.Unlock()
+		return
+	}
+	m.stop = make(chan struct{})
+	stop := m.stop
+	names := make([]string, 0, len(m.statuses))
+	for name := range m.statuses {
+		names = append(names, name)
+	}
+	m.mu.Unlock()
+
+	for _, name := range names {
+		m.wg.Add(1)
+		go func(name string) {
+			defer m.wg.Done()
+			ticker := time.NewTicker(m.Interval)
+			defer ticker.Stop()
+			for {
+				m.probe(name)
+				select {
+				case <-stop:
+					return
+				case <-ticker.C:
+				}
+			}
+		}(name)
+	}
+}
+
+// Stop stops polling and waits for probes in flight to finish.
+func (m *HealthMonitor) Stop() {
+	m.mu.Lock()
+	if m.stop == nil {
+		m.mu.Unlock()
+		return
+	}
+	close(m.stop)
+	m.stop = nil
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+func (m *HealthMonitor) probe(name string) {
+	m.mu.RLock()
+	status, ok := m.statuses[name]
+	var url string
+	if ok {
+		url = status.URL
+	}
+	m.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	check := m.request(url)
+
+	m.mu.Lock()
+	transition := !status.Checked && !check.Up || status.Checked && status.Up != check.Up
+	status.Checked = true
+	status.Up = check.Up
+	status.Latency = check.Latency
+	status.LastChecked = check.Time
+	if check.Err != "" {
+		status.LastError = check.Err
+	}
+	if transition {
+		status.Since = check.Time
+	}
+	status.History = append(status.History, check)
+	if len(status.History) > healthHistorySize {
+		status.History = status.History[len(status.History)-healthHistorySize:]
+	}
+	snapshot := status.snapshot()
+	transitions := append([]func(BackendStatus){}, m.transitions...)
+	m.mu.Unlock()
+
+	if !transition {
+		return
+	}
+	if snapshot.Up {
+		Logger().Info("Backend is up again", "backend", name)
+	} else {
+		Logger().Warn("Backend is down", "backend", name, "err", snapshot.LastError)
+	}
+	for _, fn := range transitions {
+		fn(snapshot)
+	}
+}
+
+// request probes the URL once, giving up after Timeout. The response body is always drained and closed.
+func (m *HealthMonitor) request(url string) HealthCheck {
+	ctx, cancel := context.WithTimeout(context.Background(), m.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	check := HealthCheck{Time: start}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		check.Err = Redact(err.Error())
+		return check
+	}
+	resp, err := m.client.Do(req)
+	check.Latency = time.Since(start)
+	if err != nil {
+		check.Err = Redact(err.Error())
+		return check
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		check.Err = fmt.Sprintf("responded with %v", resp.Status)
+		return check
+	}
+	check.Up = true
+	return check
+}
+
+func (s *BackendStatus) snapshot() BackendStatus {
+	snapshot := *s
+	snapshot.History = append([]HealthCheck(nil), s.History...)
+	return snapshot
+}
+
+// Status returns the cached status of the backend.
+func (m *HealthMonitor) Status(name string) (BackendStatus, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status, ok := m.statuses[name]
+	if !ok {
+		return BackendStatus{}, false
+	}
+	return status.snapshot(), true
+}
+
+// Statuses returns the cached status of every backend, sorted by name.
+func (m *HealthMonitor) Statuses() []BackendStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	statuses := make([]BackendStatus, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		statuses = append(statuses, status.snapshot())
+	}
+	sort.Slice(statuses, func(a, b int) bool { return statuses[a].Name < statuses[b].Name })
+	return statuses
+}
+
+// Check returns an error matching DeadAPI, with the reason as its cause, if the backend was down when last probed,
+// so handlers can fail fast instead of waiting for a request to time out. Unknown backends are reported as down.
+func (m *HealthMonitor) Check(name string) error {
+	if m == nil {
+		return nil
+	}
+	status, ok := m.Status(name)
+	switch {
+	case !ok:
+		return BackendUnavailableError(CodeBackendUnavailable, DeadAPI.Message, fmt.Errorf("unknown backend %q", name))
+	case !status.Up:
+		return BackendUnavailableError(CodeBackendUnavailable, DeadAPI.Message, fmt.Errorf("%v is down: %v", name, status.LastError))
+	}
+	return nil
+}
+
+// StatusEmbed shows the state of every backend.
+func StatusEmbed(guildID string, statuses []BackendStatus) *EmbedBuilder {
+	embed := NewEmbed(guildID, StyleSuccess).Title("Status").Now()
+	if len(statuses) == 0 {
+		return embed.Color(ThemeFor(guildID).Color(StyleInfo)).Description("No backends are configured.")
+	}
+
+	for _, status := range statuses {
+		if !status.Up {
+			embed.Color(ThemeFor(guildID).Color(StyleError))
+		}
+
+		var value strings.Builder
+		switch {
+		case !status.Checked:
+			value.WriteString("⏳ Not checked yet")
+		case status.Up:
+			fmt.Fprintf(&value, "🟢 Up for %v, %v", since(status.Since), status.Latency.Round(time.Millisecond))
+		default:
+			fmt.Fprintf(&value, "🔴 Down for %v", since(status.Since))
+		}
+		if !status.LastChecked.IsZero() {
+			fmt.Fprintf(&value, "\nChecked <t:%d:R>", status.LastChecked.Unix())
+		}
+		if len(status.History) > 0 {
+			value.WriteString("\n")
+			for _, check := range status.History {
+				if check.Up {
+					value.WriteString("🟩")
+				} else {
+					value.WriteString("🟥")
+				}
+			}
+		}
+		if status.LastError != "" {
+			value.WriteString("\nLast error: " + InlineCode(truncate(status.LastError, 200)))
+		}
+		embed.Field(status.Name, value.String(), false)
+	}
+	return embed
+}
+
+func since(t time.Time) time.Duration {
+	return time.Since(t).Round(time.Second)
+}
+
+// TransitionEmbed describes a backend going down or coming back up.
+func TransitionEmbed(guildID string, status BackendStatus) *discordgo.MessageEmbed {
+	if status.Up {
+		return NewEmbed(guildID, StyleSuccess).
+			Title("Backend "+status.Name+" is up").
+			Timestamp(status.Since).
+			Field("Latency", status.Latency.Round(time.Millisecond).String(), true).
+			Build()
+	}
+	return NewEmbed(guildID, StyleError).
+		Title("Backend "+status.Name+" is down").
+		Timestamp(status.Since).
+		Field("Error", codeField(status.LastError), false).
+		Build()
+}
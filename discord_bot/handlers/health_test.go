@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthMonitorCheckIsDeadAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	m := NewHealthMonitor(Backend{Name: "api", URL: server.URL})
+	m.probe("api")
+
+	for _, name := range []string{"api", "unknown"} {
+		err := m.Check(name)
+		if !errors.Is(err, DeadAPI) {
+			t.Errorf("Check(%q) = %v, want it to match DeadAPI", name, err)
+		}
+		if !errors.Is(err, ErrBackendUnavailable) {
+			t.Errorf("Check(%q) = %v, want a backend unavailable error", name, err)
+		}
+	}
+}
+
+func TestErrorIsOnlyMatchesSentinels(t *testing.T) {
+	cause := errors.New("timeout")
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"same sentinel", DeadAPI, DeadAPI, true},
+		{"sentinel with a cause", BackendUnavailableError(CodeBackendUnavailable, DeadAPI.Message, cause), DeadAPI, true},
+		{"other message", BackendUnavailableError(CodeBackendUnavailable, "", cause), DeadAPI, false},
+		{"other code", BackendUnavailableError("stable_diffusion", DeadAPI.Message, cause), DeadAPI, false},
+		{"target with a cause", DeadAPI, BackendUnavailableError(CodeBackendUnavailable, DeadAPI.Message, cause), false},
+		{"user errors", UserError("cooldown", "wait"), UserError("cooldown", "wait"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.want)
+			}
+		})
+	}
+}
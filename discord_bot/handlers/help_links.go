@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// HelpLinks maps error codes to the documentation explaining them.
+//
+// Links may contain {code}, which is replaced with the error code, so that a single Default
+// such as "https://example.com/troubleshooting#{code}" covers every code.
+type HelpLinks struct {
+	// Default is used for codes without their own link. Empty means no link.
+	Default string
+	// Codes maps an error code to its link.
+	Codes map[string]string
+}
+
+var (
+	helpLinks   HelpLinks
+	helpLinksMu sync.RWMutex
+)
+
+// SetHelpLinks validates and sets the links shown with error responses.
+func SetHelpLinks(links HelpLinks) error {
+	if err := links.Validate(); err != nil {
+		return err
+	}
+
+	helpLinksMu.Lock()
+	defer helpLinksMu.Unlock()
+	helpLinks = links
+	return nil
+}
+
+// Validate checks that Discord will accept every link on a link button.
+func (links HelpLinks) Validate() error {
+	var errs []error
+	if err := validateHelpURL(links.Default); err != nil {
+		errs = append(errs, fmt.Errorf("default help link: %w", err))
+	}
+	for code, link := range links.Codes {
+		if err := validateHelpURL(link); err != nil {
+			errs = append(errs, fmt.Errorf("help link for %v: %w", code, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// HelpURL returns the documentation link for the error code, or "" if there is none.
+func HelpURL(code string) string {
+	helpLinksMu.RLock()
+	defer helpLinksMu.RUnlock()
+
+	link, ok := helpLinks.Codes[code]
+	if !ok {
+		link = helpLinks.Default
+	}
+	return strings.ReplaceAll(link, "{code}", url.PathEscape(code))
+}
+
+// validateHelpURL checks that Discord will accept the link on a link button.
+func validateHelpURL(link string) error {
+	if link == "" {
+		return nil
+	}
+	u, err := url.Parse(strings.ReplaceAll(link, "{code}", "code"))
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute http(s) URL", link)
+	}
+	return nil
+}
+
+// LinkComponent returns the component with the url set on its link buttons.
+// Link buttons in Components have no URL of their own, so they must be sent through LinkComponent.
+func LinkComponent(id Component, url string) discordgo.MessageComponent {
+	row, ok := Components[id].(discordgo.ActionsRow)
+	if !ok {
+		return Components[id]
+	}
+
+	linked := discordgo.ActionsRow{Components: make([]discordgo.MessageComponent, 0, len(row.Components))}
+	for _, component := range row.Components {
+		if button, ok := component.(discordgo.Button); ok && button.Style == discordgo.LinkButton {
+			if url == "" {
+				continue
+			}
+			button.URL = url
+			component = button
+		}
+		linked.Components = append(linked.Components, component)
+	}
+	return linked
+}
+
+// errorComponents returns the Read more row for the error, with a Dismiss button for ephemeral messages
+// and a Delete button otherwise. The Read more button is left out when the code has no help link.
+func errorComponents(ephemeral bool, errorContent ...any) []discordgo.MessageComponent {
+	code := errorCode(errorContent...)
+	if code == "" {
+		code = CodeInternal
+	}
+	if ephemeral {
+		return []discordgo.MessageComponent{LinkComponent(readmoreDismiss, HelpURL(code))}
+	}
+	return []discordgo.MessageComponent{LinkComponent(urlDelete, HelpURL(code))}
+}
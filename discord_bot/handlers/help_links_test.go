@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// setHelpLinks sets the links for the test and clears them afterwards.
+func setHelpLinks(t *testing.T, links HelpLinks) {
+	t.Helper()
+	if err := SetHelpLinks(links); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = SetHelpLinks(HelpLinks{}) })
+}
+
+func TestHelpURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		links HelpLinks
+		code  string
+		want  string
+	}{
+		{"no links", HelpLinks{}, "cooldown", ""},
+		{"default", HelpLinks{Default: "https://example.com/errors"}, "cooldown", "https://example.com/errors"},
+		{"default with code", HelpLinks{Default: "https://example.com/errors#{code}"}, "cooldown", "https://example.com/errors#cooldown"},
+		{"code is escaped", HelpLinks{Default: "https://example.com/errors/{code}"}, "a/b c", "https://example.com/errors/a%2Fb%20c"},
+		{
+			"code link over default",
+			HelpLinks{Default: "https://example.com/errors", Codes: map[string]string{"cooldown": "https://example.com/cooldowns"}},
+			"cooldown", "https://example.com/cooldowns",
+		},
+		{
+			"other codes use the default",
+			HelpLinks{Default: "https://example.com/errors", Codes: map[string]string{"cooldown": "https://example.com/cooldowns"}},
+			CodeInternal, "https://example.com/errors",
+		},
+		{
+			"empty code link turns the default off",
+			HelpLinks{Default: "https://example.com/errors", Codes: map[string]string{"cooldown": ""}},
+			"cooldown", "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setHelpLinks(t, tt.links)
+			if got := HelpURL(tt.code); got != tt.want {
+				t.Errorf("HelpURL(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelpLinksValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		links   HelpLinks
+		wantErr bool
+	}{
+		{"empty", HelpLinks{}, false},
+		{"with code", HelpLinks{Default: "https://example.com/{code}", Codes: map[string]string{"x": "http://example.com"}}, false},
+		{"relative", HelpLinks{Default: "/errors"}, true},
+		{"other scheme", HelpLinks{Default: "ftp://example.com"}, true},
+		{"no host", HelpLinks{Default: "https://"}, true},
+		{"bad code link", HelpLinks{Default: "https://example.com", Codes: map[string]string{"x": "not a link"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.links.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr && SetHelpLinks(tt.links) == nil {
+				t.Error("SetHelpLinks() accepted invalid links")
+			}
+		})
+	}
+}
+
+// linkURLs returns the URLs of the link buttons in the rows, and how many buttons there are.
+func linkURLs(rows []discordgo.MessageComponent) (urls []string, buttons int) {
+	for _, row := range rows {
+		for _, c := range row.(discordgo.ActionsRow).Components {
+			buttons++
+			if button := c.(discordgo.Button); button.Style == discordgo.LinkButton {
+				urls = append(urls, button.URL)
+			}
+		}
+	}
+	return urls, buttons
+}
+
+func TestErrorComponentsLinkTheCode(t *testing.T) {
+	setHelpLinks(t, HelpLinks{Codes: map[string]string{
+		"cooldown":   "https://example.com/cooldowns",
+		CodeInternal: "https://example.com/internal",
+	}})
+
+	tests := []struct {
+		name      string
+		err       error
+		ephemeral bool
+		wantURL   string
+	}{
+		{"code with a link", UserError("cooldown", "Slow down."), true, "https://example.com/cooldowns"},
+		{"not ephemeral", UserError("cooldown", "Slow down."), false, "https://example.com/cooldowns"},
+		{"plain errors are internal", errors.New("boom"), true, "https://example.com/internal"},
+		{"code without a link", PermissionError("", "No."), true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			urls, buttons := linkURLs(errorComponents(tt.ephemeral, tt.err))
+			switch {
+			case tt.wantURL == "" && (len(urls) != 0 || buttons != 1):
+				t.Errorf("links %v among %v buttons, want only the dismiss or delete button", urls, buttons)
+			case tt.wantURL != "" && (len(urls) != 1 || urls[0] != tt.wantURL || buttons != 2):
+				t.Errorf("links %v among %v buttons, want Read more linking %v", urls, buttons, tt.wantURL)
+			}
+		})
+	}
+}
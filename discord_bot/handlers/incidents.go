@@ -0,0 +1,216 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultIncidentCapacity is how many incidents are kept before the oldest are dropped.
+const DefaultIncidentCapacity = 500
+
+// Incident records the context of an internal or backend error shown to a user, so that it can be found again
+// from its ID. Errors the user can fix themselves, such as invalid input or missing permissions, aren't incidents.
+type Incident struct {
+	ID            string            `json:"incident_id"`
+	Time          time.Time         `json:"time"`
+	Code          string            `json:"code,omitempty"`
+	InteractionID string            `json:"interaction_id,omitempty"`
+	GuildID       string            `json:"guild_id,omitempty"`
+	ChannelID     string            `json:"channel_id,omitempty"`
+	UserID        string            `json:"user_id,omitempty"`
+	Command       string            `json:"command,omitempty"`
+	Options       map[string]string `json:"options,omitempty"`
+	Errors        []string          `json:"errors"`
+	Stack         string            `json:"stack,omitempty"`
+}
+
+// IncidentStore keeps the most recent incidents in memory.
+type IncidentStore struct {
+	mu       sync.RWMutex
+	capacity int
+	order    []string
+	byID     map[string]*Incident
+}
+
+func NewIncidentStore(capacity int) *IncidentStore {
+	return &IncidentStore{
+		capacity: max(capacity, 1),
+		byID:     make(map[string]*Incident),
+	}
+}
+
+// Incidents is the store errorEmbed records incidents in.
+var Incidents = NewIncidentStore(DefaultIncidentCapacity)
+
+func (s *IncidentStore) Add(incident *Incident) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.order) >= s.capacity {
+		delete(s.byID, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.order = append(s.order, incident.ID)
+	s.byID[incident.ID] = incident
+}
+
+// Lookup returns the incident with the ID, ignoring case and surrounding whitespace.
+func (s *IncidentStore) Lookup(id string) (*Incident, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	incident, ok := s.byID[strings.ToUpper(strings.TrimSpace(id))]
+	return incident, ok
+}
+
+// isIncident reports whether errorContent holds an internal or backend error, which are worth an incident.
+func isIncident(errorContent ...any) bool {
+	for _, content := range errorContent {
+		switch content := content.(type) {
+		case error:
+			if e := AsError(content); e.Kind == ErrInternal || e.Kind == ErrBackendUnavailable {
+				return true
+			}
+		case []any:
+			if isIncident(content...) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errorStack returns the stack recorded by InternalError for the first error in errorContent that has one.
+func errorStack(errorContent ...any) string {
+	for _, content := range errorContent {
+		switch content := content.(type) {
+		case error:
+			if stack := AsError(content).stack; stack != "" {
+				return stack
+			}
+		case []any:
+			if stack := errorStack(content...); stack != "" {
+				return stack
+			}
+		}
+	}
+	return ""
+}
+
+// newIncident records an incident for the interaction and errors, logs it as a JSON record
+// and forwards it to the error reporter if one is set.
+func newIncident(i *discordgo.Interaction, errorContent ...any) *Incident {
+	incident := &Incident{
+		ID:     incidentID(),
+		Time:   time.Now(),
+		Code:   errorCode(errorContent...),
+		Errors: errorChain(errorContent...),
+		Stack:  errorStack(errorContent...),
+	}
+	if len(incident.Errors) == 0 {
+		incident.Errors = []string{formatError(errorContent...)}
+	}
+
+	if i != nil {
+		incident.InteractionID = i.ID
+		incident.GuildID = i.GuildID
+		incident.ChannelID = i.ChannelID
+		incident.UserID = UserID(i)
+		incident.Command = InteractionName(i)
+		switch i.Type {
+		case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+			incident.Options = flattenOptions("", i.ApplicationCommandData().Options)
+		}
+	}
+
+	for n := range incident.Errors {
+		incident.Errors[n] = *sanitizeToken(&incident.Errors[n])
+	}
+
+	Incidents.Add(incident)
+
+	record, err := json.Marshal(incident)
+	if err != nil {
+		InteractionLogger(i).Error("Incident", "incident", incident.ID, "code", incident.Code, "errors", incident.Errors, "encode_err", err)
+	} else {
+		InteractionLogger(i).Error("Incident", "incident", incident.ID, "code", incident.Code, "record", string(record))
+	}
+
+	reportIncident(incident)
+	return incident
+}
+
+func flattenOptions(prefix string, options []*discordgo.ApplicationCommandInteractionDataOption) map[string]string {
+	flattened := make(map[string]string)
+	for _, opt := range options {
+		name := prefix + opt.Name
+		switch opt.Type {
+		case discordgo.ApplicationCommandOptionSubCommand, discordgo.ApplicationCommandOptionSubCommandGroup:
+			for k, v := range flattenOptions(name+" ", opt.Options) {
+				flattened[k] = v
+			}
+		default:
+			flattened[name] = fmt.Sprint(opt.Value)
+		}
+	}
+	return flattened
+}
+
+// incidentID returns a short random ID that is easy to read out and type.
+func incidentID() string {
+	b := make([]byte, 5)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08X", time.Now().UnixNano()&0xFFFFFFFF)
+	}
+	return base32.StdEncoding.EncodeToString(b)
+}
+
+// IncidentEmbed describes the incident for admins.
+func IncidentEmbed(guildID string, incident *Incident) *EmbedBuilder {
+	embed := NewEmbed(guildID, StyleWarning).
+		Title("Incident "+incident.ID).
+		Timestamp(incident.Time).
+		Field("Command", orNone(incident.Command), true).
+		Field("Code", orNone(incident.Code), true).
+		Field("User", mentionOrNone("<@%v>", incident.UserID), true).
+		Field("Channel", mentionOrNone("<#%v>", incident.ChannelID), true).
+		Field("Guild", orNone(incident.GuildID), true).
+		Field("Interaction", orNone(incident.InteractionID), true)
+
+	if len(incident.Options) > 0 {
+		var options []string
+		for k, v := range incident.Options {
+			options = append(options, k+": "+v)
+		}
+		embed.Field("Options", codeField(strings.Join(options, "\n")), false)
+	}
+	embed.Field("Errors", codeField(strings.Join(incident.Errors, "\n")), false)
+	if incident.Stack != "" {
+		embed.Field("Stack", codeField(incident.Stack), false)
+	}
+	return embed
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return s
+}
+
+func mentionOrNone(format, id string) string {
+	if id == "" {
+		return "none"
+	}
+	return fmt.Sprintf(format, id)
+}
+
+// codeField wraps s in a code block that fits in an embed field.
+func codeField(s string) string {
+	return CodeBlock("", truncate(s, embedFieldValueLimit-10))
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+var (
+	logger   *log.Logger
+	loggerMu sync.RWMutex
+)
+
+// SetLogger sets the logger used by the handlers package. nil restores log.Default.
+func SetLogger(l *log.Logger) {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	logger = l
+}
+
+// Logger returns the logger set with SetLogger, or log.Default if none was set.
+func Logger() *log.Logger {
+	loggerMu.RLock()
+	defer loggerMu.RUnlock()
+	if logger == nil {
+		return log.Default()
+	}
+	return logger
+}
+
+// InteractionLogger returns a Logger adding the interaction ID, guild, channel, user and command to every record.
+// It returns Logger itself for a nil interaction.
+func InteractionLogger(i *discordgo.Interaction) *log.Logger {
+	if i == nil {
+		return Logger()
+	}
+	return Logger().With(
+		"interaction", i.ID,
+		"guild", i.GuildID,
+		"channel", i.ChannelID,
+		"user", UserID(i),
+		"command", InteractionName(i),
+	)
+}
+
+// InteractionName describes what triggered the interaction: "/name" for commands and autocomplete,
+// "component:custom_id" for components and "modal:custom_id" for modals.
+func InteractionName(i *discordgo.Interaction) string {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		return "/" + i.ApplicationCommandData().Name
+	case discordgo.InteractionMessageComponent:
+		return "component:" + i.MessageComponentData().CustomID
+	case discordgo.InteractionModalSubmit:
+		return "modal:" + i.ModalSubmitData().CustomID
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// zeroWidthSpace breaks up mentions and code fences without changing how the text looks.
+const zeroWidthSpace = "\u200b"
+
+// noMentions is the default for every response: the text is shown as is, but nobody is pinged.
+func noMentions() *discordgo.MessageAllowedMentions {
+	return &discordgo.MessageAllowedMentions{Parse: []discordgo.AllowedMentionType{}}
+}
+
+// allowedMentions returns the mentions the content opts into, or no mentions at all.
+//
+// Handlers opt in per mention type by passing a discordgo.AllowedMentionType (e.g. discordgo.AllowedMentionTypeUsers)
+// as content, or take full control by passing a *discordgo.MessageAllowedMentions.
+func allowedMentions(content ...any) *discordgo.MessageAllowedMentions {
+	mentions := noMentions()
+	for _, c := range content {
+		switch c := c.(type) {
+		case discordgo.AllowedMentionType:
+			mentions.Parse = append(mentions.Parse, c)
+		case *discordgo.MessageAllowedMentions:
+			if c != nil {
+				return c
+			}
+		case discordgo.MessageAllowedMentions:
+			return &c
+		}
+	}
+	return mentions
+}
+
+var (
+	markdownEscaper = regexp.MustCompile("([\\\\*_~`|>#\\-\\[\\]()])")
+	mentionEscaper  = regexp.MustCompile(`@(everyone|here)|<(@[!&]?|#)(\d+)>`)
+)
+
+// EscapeMarkdown escapes markdown formatting so that the text is shown literally.
+func EscapeMarkdown(s string) string {
+	return markdownEscaper.ReplaceAllString(s, `\$1`)
+}
+
+// EscapeMentions breaks up @everyone, @here, and user, role and channel mentions so that they aren't rendered.
+func EscapeMentions(s string) string {
+	return mentionEscaper.ReplaceAllStringFunc(s, func(mention string) string {
+		if strings.HasPrefix(mention, "@") {
+			return "@" + zeroWidthSpace + mention[1:]
+		}
+		return "<" + zeroWidthSpace + mention[1:]
+	})
+}
+
+// EscapeCodeBlock makes the text safe to put inside a ``` code block.
+func EscapeCodeBlock(s string) string {
+	return strings.ReplaceAll(s, "```", "`"+zeroWidthSpace+"``")
+}
+
+// InlineCode wraps the text in an inline code span. Backticks can't be escaped in inline code,
+// so they're replaced with a similar looking character.
+func InlineCode(s string) string {
+	return "`" + strings.ReplaceAll(s, "`", "ˋ") + "`"
+}
+
+// CodeBlock wraps the text in a ``` code block with an optional language.
+func CodeBlock(language, s string) string {
+	return "```" + language + "\n" + EscapeCodeBlock(s) + "\n```"
+}
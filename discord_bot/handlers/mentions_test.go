@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEscapeMarkdown(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"plain text", "plain text"},
+		{"**bold** and _italic_", `\*\*bold\*\* and \_italic\_`},
+		{"~~strike~~ ||spoiler||", `\~\~strike\~\~ \|\|spoiler\|\|`},
+		{"`code`", "\\`code\\`"},
+		{"> quote\n# heading\n- item", "\\> quote\n\\# heading\n\\- item"},
+		{"[link](https://example.com)", `\[link\]\(https://example.com\)`},
+		{`already \* escaped`, `already \\\* escaped`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := EscapeMarkdown(tt.in); got != tt.want {
+			t.Errorf("EscapeMarkdown(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestEscapeMentions(t *testing.T) {
+	const zws = zeroWidthSpace
+	tests := []struct {
+		in, want string
+	}{
+		{"hello", "hello"},
+		{"@everyone look", "@" + zws + "everyone look"},
+		{"hey @here!", "hey @" + zws + "here!"},
+		{"<@123>", "<" + zws + "@123>"},
+		{"<@!123>", "<" + zws + "@!123>"},
+		{"<@&456>", "<" + zws + "@&456>"},
+		{"<#789>", "<" + zws + "#789>"},
+		{"@everyone and <@123>", "@" + zws + "everyone and <" + zws + "@123>"},
+		// not mentions, left alone
+		{"user@example.com", "user@example.com"},
+		{"<@name>", "<@name>"},
+		{"@someone", "@someone"},
+		{"<:emoji:123>", "<:emoji:123>"},
+	}
+	for _, tt := range tests {
+		if got := EscapeMentions(tt.in); got != tt.want {
+			t.Errorf("EscapeMentions(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCodeEscaping(t *testing.T) {
+	if got, want := CodeBlock("go", "a ``` b"), "```go\na `"+zeroWidthSpace+"`` b\n```"; got != want {
+		t.Errorf("CodeBlock() = %q, want %q", got, want)
+	}
+	if got, want := InlineCode("a `b`"), "`a ˋbˋ`"; got != want {
+		t.Errorf("InlineCode() = %q, want %q", got, want)
+	}
+}
+
+func TestAllowedMentions(t *testing.T) {
+	custom := &discordgo.MessageAllowedMentions{Users: []string{"123"}}
+
+	tests := []struct {
+		name    string
+		content []any
+		want    *discordgo.MessageAllowedMentions
+	}{
+		{"nothing", nil, noMentions()},
+		{"other content", []any{"text", &discordgo.MessageEmbed{}}, noMentions()},
+		{"one type", []any{"text", discordgo.AllowedMentionTypeUsers}, &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers},
+		}},
+		{"several types", []any{discordgo.AllowedMentionTypeUsers, discordgo.AllowedMentionTypeRoles}, &discordgo.MessageAllowedMentions{
+			Parse: []discordgo.AllowedMentionType{discordgo.AllowedMentionTypeUsers, discordgo.AllowedMentionTypeRoles},
+		}},
+		{"pointer", []any{discordgo.AllowedMentionTypeEveryone, custom}, custom},
+		{"value", []any{*custom}, custom},
+		{"nil pointer", []any{(*discordgo.MessageAllowedMentions)(nil)}, noMentions()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := allowedMentions(tt.content...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allowedMentions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// an empty, non-nil Parse is what stops Discord from parsing mentions out of the content
+	if parse := noMentions().Parse; parse == nil || len(parse) != 0 {
+		t.Errorf("noMentions().Parse = %#v, want an empty list", parse)
+	}
+}
+
+func TestResponsesDontPingByDefault(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	Responses[EphemeralContent].(MsgResponseType)(session, testInteraction(time.Now()), "hi @everyone and <@123>")
+
+	requests := fake.received()
+	if len(requests) != 1 {
+		t.Fatalf("sent %v requests, want one response", len(requests))
+	}
+	if !strings.Contains(requests[0].Body, `"allowed_mentions":{"parse":[],`) {
+		t.Errorf("the response %v doesn't disable mentions", requests[0].Body)
+	}
+}
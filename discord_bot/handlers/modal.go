@@ -0,0 +1,304 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// DefaultModalTimeout is how long AwaitModal waits for the user to submit the modal.
+const DefaultModalTimeout = 10 * time.Minute
+
+const maxModalInputs = 5
+
+// ModalValidationError lists every field of a modal submission that failed validation.
+type ModalValidationError struct {
+	Problems []string
+}
+
+func (e *ModalValidationError) Error() string {
+	return "invalid input:\n" + strings.Join(e.Problems, "\n")
+}
+
+// userError returns the user error listing the problems, it wraps e.
+func (e *ModalValidationError) userError() *Error {
+	return &Error{Kind: ErrUser, Code: "invalid_input", Message: strings.Join(e.Problems, "\n"), Err: e}
+}
+
+// modalField describes a text input built from a struct field.
+//
+// Fields are configured with struct tags:
+//
+//	type Settings struct {
+//		Prompt string `modal:"prompt,required,paragraph,max=2000" label:"Prompt" placeholder:"masterpiece"`
+//		Steps  int    `modal:"steps,min=1,max=3" label:"Steps"`
+//	}
+//
+// The first value of the modal tag is the custom ID, which defaults to the lowercase field name.
+// min and max are the minimum and maximum length of the text.
+// Fields tagged with `modal:"-"` are skipped.
+type modalField struct {
+	index       int
+	customID    string
+	label       string
+	placeholder string
+	style       discordgo.TextInputStyle
+	required    bool
+	minLength   int
+	maxLength   int
+}
+
+func modalFields(t reflect.Type) ([]modalField, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("modal must be built from a struct, got %v", t)
+	}
+
+	var fields []modalField
+	for n := 0; n < t.NumField(); n++ {
+		sf := t.Field(n)
+		tag := sf.Tag.Get("modal")
+		if !sf.IsExported() || tag == "-" {
+			continue
+		}
+
+		switch sf.Type.Kind() {
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64, reflect.Bool:
+		default:
+			return nil, fmt.Errorf("field %v has unsupported type %v", sf.Name, sf.Type)
+		}
+
+		field := modalField{
+			index:       n,
+			customID:    strings.ToLower(sf.Name),
+			label:       sf.Tag.Get("label"),
+			placeholder: sf.Tag.Get("placeholder"),
+			style:       discordgo.TextInputShort,
+		}
+		if field.label == "" {
+			field.label = sf.Name
+		}
+
+		for i, opt := range strings.Split(tag, ",") {
+			key, value, _ := strings.Cut(opt, "=")
+			var err error
+			switch {
+			case i == 0:
+				if key != "" {
+					field.customID = key
+				}
+			case key == "required":
+				field.required = true
+			case key == "paragraph":
+				field.style = discordgo.TextInputParagraph
+			case key == "min":
+				field.minLength, err = strconv.Atoi(value)
+			case key == "max":
+				field.maxLength, err = strconv.Atoi(value)
+			default:
+				err = fmt.Errorf("unknown option %q", key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("field %v: %w", sf.Name, err)
+			}
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, errors.New("modal has no fields")
+	}
+	if len(fields) > maxModalInputs {
+		return nil, fmt.Errorf("modal has %d fields, but Discord allows at most %d", len(fields), maxModalInputs)
+	}
+	return fields, nil
+}
+
+// BuildModal returns the modal for the struct pointed to by v. The current values of v are used to prefill the inputs.
+func BuildModal(customID, title string, v any) (*discordgo.InteractionResponseData, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fields, err := modalFields(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]discordgo.MessageComponent, len(fields))
+	for n, field := range fields {
+		var value string
+		if fv := rv.Field(field.index); !fv.IsZero() {
+			value = fmt.Sprint(fv.Interface())
+		}
+		components[n] = discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.TextInput{
+					CustomID:    field.customID,
+					Label:       field.label,
+					Style:       field.style,
+					Placeholder: field.placeholder,
+					Value:       value,
+					Required:    field.required,
+					MinLength:   field.minLength,
+					MaxLength:   field.maxLength,
+				},
+			},
+		}
+	}
+
+	return &discordgo.InteractionResponseData{
+		CustomID:   customID,
+		Title:      truncate(title, 45),
+		Components: components,
+	}, nil
+}
+
+// DecodeModal decodes a modal submission into the struct pointed to by v, validating every field.
+// Validation problems are returned together as a *ModalValidationError.
+func DecodeModal(data discordgo.ModalSubmitInteractionData, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("DecodeModal requires a non-nil pointer, got %T", v)
+	}
+	rv = rv.Elem()
+
+	fields, err := modalFields(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	inputs := ModalInputs(data)
+	var problems []string
+	for _, field := range fields {
+		var value string
+		if input, ok := inputs[Component(field.customID)]; ok {
+			value = strings.TrimSpace(input.Value)
+		}
+
+		switch {
+		case value == "" && field.required:
+			problems = append(problems, fmt.Sprintf("%v is required", field.label))
+			continue
+		case value == "":
+			continue
+		case field.minLength > 0 && len([]rune(value)) < field.minLength:
+			problems = append(problems, fmt.Sprintf("%v must be at least %d characters", field.label, field.minLength))
+			continue
+		case field.maxLength > 0 && len([]rune(value)) > field.maxLength:
+			problems = append(problems, fmt.Sprintf("%v must be at most %d characters", field.label, field.maxLength))
+			continue
+		}
+
+		if err := setField(rv.Field(field.index), value); err != nil {
+			problems = append(problems, fmt.Sprintf("%v %v", field.label, err))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ModalValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func setField(field reflect.Value, value string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a positive whole number")
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return errors.New("must be a number")
+		}
+		field.SetFloat(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		field.SetBool(b)
+	}
+	return nil
+}
+
+// ModalInputs returns the text inputs of a modal submission by custom ID.
+// Components that aren't text inputs are skipped.
+func ModalInputs(data discordgo.ModalSubmitInteractionData) map[Component]*discordgo.TextInput {
+	inputs := make(map[Component]*discordgo.TextInput)
+	for _, row := range data.Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, c := range actionsRow.Components {
+			if textInput, ok := c.(*discordgo.TextInput); ok {
+				inputs[Component(textInput.CustomID)] = textInput
+			}
+		}
+	}
+	return inputs
+}
+
+// AwaitModal opens a modal built from the struct pointed to by v, waits for the user to submit it and decodes the
+// submission back into v. It must be used as the first response to the interaction.
+//
+// Validation errors are reported to the user ephemerally on the submission and returned as a user *Error wrapping the
+// *ModalValidationError, joined with ErrHandled so the dispatcher doesn't report them a second time.
+// On success the submission is returned and must be responded to by the caller.
+func AwaitModal(ctx context.Context, bot *discordgo.Session, i *discordgo.Interaction, title string, v any) (*discordgo.Interaction, error) {
+	customID := "modal_" + i.ID
+	data, err := BuildModal(customID, title, v)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := NewCollector(bot, CollectorOptions{
+		UserID:   UserID(i),
+		CustomID: regexp.MustCompile(`^` + regexp.QuoteMeta(customID) + `$`),
+		Types:    []discordgo.InteractionType{discordgo.InteractionModalSubmit},
+		Max:      1,
+		Timeout:  DefaultModalTimeout,
+	})
+	defer collector.Stop()
+
+	err = bot.InteractionRespond(i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	submission, err := collector.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := DecodeModal(submission.ModalSubmitData(), v); err != nil {
+		var invalid *ModalValidationError
+		if errors.As(err, &invalid) {
+			err = invalid.userError()
+		}
+		Errors[ErrorEphemeral](bot, submission, err)
+		return submission, fmt.Errorf("%w: %w", ErrHandled, err)
+	}
+	return submission, nil
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type imagineModal struct {
+	Prompt   string  `modal:"prompt,required,paragraph,max=20" label:"Prompt"`
+	Negative string  `modal:"negative,min=3" label:"Negative prompt"`
+	Steps    int     `modal:"steps" label:"Steps"`
+	Seed     uint64  `modal:"seed" label:"Seed"`
+	HiRes    bool    `modal:"hires" label:"Hi-res fix"`
+	Scale    float64 `modal:"-"`
+}
+
+// submission returns modal data with a text input for every value, keyed by custom ID.
+func submission(values map[string]string) discordgo.ModalSubmitInteractionData {
+	data := discordgo.ModalSubmitInteractionData{CustomID: "modal"}
+	for id, value := range values {
+		data.Components = append(data.Components, &discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{&discordgo.TextInput{CustomID: id, Value: value}},
+		})
+	}
+	// components other than text inputs are skipped
+	data.Components = append(data.Components, &discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{&discordgo.Button{CustomID: "button"}},
+	})
+	return data
+}
+
+func TestDecodeModal(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   map[string]string
+		want     imagineModal
+		problems []string
+	}{
+		{
+			name:   "every field",
+			values: map[string]string{"prompt": " a cat ", "negative": "dogs", "steps": "20", "seed": "42", "scale": "7.5", "hires": "true"},
+			want:   imagineModal{Prompt: "a cat", Negative: "dogs", Steps: 20, Seed: 42, HiRes: true},
+		},
+		{
+			name:   "optional fields left empty",
+			values: map[string]string{"prompt": "a cat", "steps": "  "},
+			want:   imagineModal{Prompt: "a cat"},
+		},
+		{
+			name:     "missing required field",
+			values:   map[string]string{"negative": "dogs"},
+			want:     imagineModal{Negative: "dogs"},
+			problems: []string{"Prompt is required"},
+		},
+		{
+			name:     "lengths",
+			values:   map[string]string{"prompt": "a very long prompt indeed", "negative": "no"},
+			problems: []string{"Prompt must be at most 20 characters", "Negative prompt must be at least 3 characters"},
+		},
+		{
+			name:   "invalid numbers",
+			values: map[string]string{"prompt": "a cat", "steps": "many", "seed": "-1", "hires": "maybe"},
+			want:   imagineModal{Prompt: "a cat"},
+			problems: []string{
+				"Steps must be a whole number",
+				"Seed must be a positive whole number",
+				"Hi-res fix must be true or false",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got imagineModal
+			err := DecodeModal(submission(tt.values), &got)
+
+			var invalid *ModalValidationError
+			switch {
+			case tt.problems == nil && err != nil:
+				t.Fatalf("DecodeModal() = %v", err)
+			case tt.problems != nil && !errors.As(err, &invalid):
+				t.Fatalf("DecodeModal() = %v, want a *ModalValidationError", err)
+			case tt.problems != nil && !reflect.DeepEqual(invalid.Problems, tt.problems):
+				t.Errorf("problems = %q, want %q", invalid.Problems, tt.problems)
+			}
+			if tt.problems == nil && got != tt.want {
+				t.Errorf("decoded %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeModalRequiresStructPointer(t *testing.T) {
+	data := submission(nil)
+	for _, v := range []any{imagineModal{}, (*imagineModal)(nil), new(string), new(struct{ Tags []string })} {
+		err := DecodeModal(data, v)
+		var invalid *ModalValidationError
+		if err == nil || errors.As(err, &invalid) {
+			t.Errorf("DecodeModal(%T) = %v, want a usage error", v, err)
+		}
+	}
+}
+
+func TestAwaitModalReportsValidationErrorsOnce(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	i := testInteraction(time.Now())
+
+	type result struct {
+		submission *discordgo.Interaction
+		err        error
+	}
+	done := make(chan result, 1)
+	go func() {
+		var v imagineModal
+		submission, err := AwaitModal(context.Background(), session, i, "Imagine", &v)
+		done <- result{submission, err}
+	}()
+
+	deadline := time.Now().Add(time.Second)
+	for len(fake.received()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the modal was never opened")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	submit := testInteraction(time.Now())
+	submit.ID = snowflakeAt(time.Now().Add(time.Millisecond))
+	submit.Type = discordgo.InteractionModalSubmit
+	submit.Data = submission(map[string]string{"prompt": "a cat", "steps": "many"})
+	data := submit.Data.(discordgo.ModalSubmitInteractionData)
+	data.CustomID = "modal_" + i.ID
+	submit.Data = data
+	if !Collect(session, submit) {
+		t.Fatal("the submission wasn't collected")
+	}
+
+	got := <-done
+	if got.submission != submit {
+		t.Errorf("AwaitModal() returned %v, want the submission", got.submission)
+	}
+	if !errors.Is(got.err, ErrHandled) || !errors.Is(got.err, ErrUser) {
+		t.Fatalf("AwaitModal() = %v, want a handled user error", got.err)
+	}
+
+	RespondError(session, i, got.err)
+	requests := fake.received()
+	if len(requests) != 2 {
+		t.Fatalf("sent %v requests, want the modal and one error response", len(requests))
+	}
+	if resp := callback(t, requests[1]); resp.Data.Flags&discordgo.MessageFlagsEphemeral == 0 {
+		t.Errorf("the validation error was sent with flags %v, want it ephemeral", resp.Data.Flags)
+	}
+}
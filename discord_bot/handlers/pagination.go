@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Page is a single page rendered by a Paginator.
+type Page struct {
+	Content string
+	Embed   *EmbedBuilder
+}
+
+// PageSource provides the pages for a Paginator.
+type PageSource interface {
+	// Len returns the total number of pages.
+	Len() int
+	// Page returns the page at index, starting at 0.
+	Page(index int) (Page, error)
+}
+
+// StaticPages is a PageSource backed by a slice.
+type StaticPages []Page
+
+func (p StaticPages) Len() int { return len(p) }
+
+func (p StaticPages) Page(index int) (Page, error) {
+	if index < 0 || index >= len(p) {
+		return Page{}, fmt.Errorf("page %d out of range", index+1)
+	}
+	return p[index], nil
+}
+
+// LazyPages returns a PageSource that calls load the first time each page is shown and caches the result.
+func LazyPages(total int, load func(index int) (Page, error)) PageSource {
+	return &lazyPages{total: total, load: load, cache: make(map[int]Page)}
+}
+
+type lazyPages struct {
+	total int
+	load  func(index int) (Page, error)
+
+	mu    sync.Mutex
+	cache map[int]Page
+}
+
+func (p *lazyPages) Len() int { return p.total }
+
+func (p *lazyPages) Page(index int) (Page, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if page, ok := p.cache[index]; ok {
+		return page, nil
+	}
+	page, err := p.load(index)
+	if err != nil {
+		return Page{}, err
+	}
+	p.cache[index] = page
+	return page, nil
+}
+
+// DefaultPaginationTimeout is how long a Paginator waits for input before disabling its buttons.
+const DefaultPaginationTimeout = 3 * time.Minute
+
+// PaginatorOptions configures a Paginator. Empty fields use the defaults.
+type PaginatorOptions struct {
+	// Timeout is how long the paginator waits for input before disabling its buttons, DefaultPaginationTimeout if zero.
+	Timeout time.Duration
+}
+
+// Paginator shows one page of a PageSource at a time with First/Previous/Jump/Next/Last buttons.
+// Only the user who invoked the interaction can change pages.
+type Paginator struct {
+	Source PageSource
+
+	bot         *discordgo.Session
+	interaction *discordgo.Interaction
+	timeout     time.Duration
+	ownerID     string
+	channelID   string
+	messageID   string
+
+	mu    sync.Mutex
+	page  int
+	timer *time.Timer
+}
+
+var (
+	paginators   = make(map[string]*Paginator)
+	paginatorsMu sync.Mutex
+)
+
+// Paginate responds to the interaction with the first page of source and starts listening for the pagination buttons.
+func Paginate(bot *discordgo.Session, i *discordgo.Interaction, source PageSource, opts PaginatorOptions) (*Paginator, error) {
+	if source.Len() < 1 {
+		return nil, errors.New("there is nothing to show")
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultPaginationTimeout
+	}
+
+	p := &Paginator{
+		Source:      source,
+		bot:         bot,
+		interaction: i,
+		timeout:     opts.Timeout,
+		ownerID:     UserID(i),
+	}
+
+	data, err := p.render(0)
+	if err != nil {
+		return nil, err
+	}
+	err = respond(bot, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := retryIdempotent(i, "interaction_response", nil, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return bot.InteractionResponse(i, options...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.channelID, p.messageID = msg.ChannelID, msg.ID
+
+	paginatorsMu.Lock()
+	paginators[p.messageID] = p
+	paginatorsMu.Unlock()
+
+	p.mu.Lock()
+	p.timer = time.AfterFunc(p.timeout, p.expire)
+	p.mu.Unlock()
+
+	return p, nil
+}
+
+// HandlePagination handles the pagination buttons. Register it for each of the Pagination components.
+func HandlePagination(bot *discordgo.Session, i *discordgo.Interaction) {
+	p, ok := paginatorFor(bot, i)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	page := p.page
+	p.mu.Unlock()
+
+	switch Component(i.MessageComponentData().CustomID) {
+	case PaginationFirst:
+		page = 0
+	case PaginationPrevious:
+		page--
+	case PaginationNext:
+		page++
+	case PaginationLast:
+		page = p.Source.Len() - 1
+	case PaginationJump:
+		p.openJumpModal(bot, i)
+		return
+	}
+
+	p.show(bot, i, page)
+}
+
+// HandlePaginationModal handles the jump-to-page modal opened by the PaginationJump button.
+func HandlePaginationModal(bot *discordgo.Session, i *discordgo.Interaction) {
+	p, ok := paginatorFor(bot, i)
+	if !ok {
+		return
+	}
+
+	var input string
+	if textInput, ok := ModalInputs(i.ModalSubmitData())[paginationPageInput]; ok {
+		input = textInput.Value
+	}
+
+	page, err := strconv.Atoi(strings.TrimSpace(input))
+	if err != nil || page < 1 || page > p.Source.Len() {
+		Responses[EphemeralContent].(MsgResponseType)(bot, i, Message(i, MessagePaginationInvalidPage, map[string]any{"Pages": p.Source.Len()}))
+		return
+	}
+
+	p.show(bot, i, page-1)
+}
+
+// paginatorFor returns the Paginator for the message the interaction came from and checks that the user is allowed to use it.
+func paginatorFor(bot *discordgo.Session, i *discordgo.Interaction) (*Paginator, bool) {
+	var p *Paginator
+	var ok bool
+	if i.Message != nil {
+		paginatorsMu.Lock()
+		p, ok = paginators[i.Message.ID]
+		paginatorsMu.Unlock()
+	}
+	if !ok {
+		Responses[EphemeralContent].(MsgResponseType)(bot, i, Message(i, MessagePaginationExpired, nil))
+		return nil, false
+	}
+
+	if UserID(i) != p.ownerID {
+		Responses[EphemeralContent].(MsgResponseType)(bot, i, Message(i, MessagePaginationNotOwner, nil))
+		return nil, false
+	}
+
+	p.mu.Lock()
+	if p.timer != nil {
+		p.timer.Reset(p.timeout)
+	}
+	p.mu.Unlock()
+
+	return p, true
+}
+
+func (p *Paginator) show(bot *discordgo.Session, i *discordgo.Interaction, page int) {
+	page = min(max(page, 0), p.Source.Len()-1)
+	data, err := p.render(page)
+	if err != nil {
+		Errors[ErrorEphemeral](bot, i, err)
+		return
+	}
+
+	p.mu.Lock()
+	p.page = page
+	p.mu.Unlock()
+
+	err = respond(bot, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: data,
+	})
+	if err != nil {
+		Errors[ErrorFollowupEphemeral](bot, i, err)
+	}
+}
+
+func (p *Paginator) render(index int) (*discordgo.InteractionResponseData, error) {
+	page, err := p.Source.Page(index)
+	if err != nil {
+		return nil, fmt.Errorf("could not load page %d: %w", index+1, err)
+	}
+
+	data := &discordgo.InteractionResponseData{
+		Content:         page.Content,
+		Components:      []discordgo.MessageComponent{p.controls(index, false)},
+		AllowedMentions: noMentions(),
+	}
+	if page.Embed != nil {
+		embed := page.Embed.Build()
+		if embed.Footer == nil {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: pageCounter(index, p.Source.Len())}
+		}
+		data.Embeds = []*discordgo.MessageEmbed{embed}
+		data.Files = page.Embed.Files()
+	}
+	return data, nil
+}
+
+// controls returns the pagination row for the page, disabling the buttons that can't be used.
+func (p *Paginator) controls(index int, disabled bool) discordgo.ActionsRow {
+	last := p.Source.Len() - 1
+	row := Components[paginationButtons].(discordgo.ActionsRow)
+	buttons := make([]discordgo.MessageComponent, len(row.Components))
+	for n, c := range row.Components {
+		button := c.(discordgo.Button)
+		switch Component(button.CustomID) {
+		case PaginationFirst, PaginationPrevious:
+			button.Disabled = disabled || index == 0
+		case PaginationNext, PaginationLast:
+			button.Disabled = disabled || index == last
+		case PaginationJump:
+			button.Label = pageCounter(index, p.Source.Len())
+			button.Disabled = disabled || last == 0
+		}
+		buttons[n] = button
+	}
+	return discordgo.ActionsRow{Components: buttons}
+}
+
+func (p *Paginator) openJumpModal(bot *discordgo.Session, i *discordgo.Interaction) {
+	err := respond(bot, i, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: string(PaginationJumpModal),
+			Title:    "Jump to page",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    string(paginationPageInput),
+							Label:       fmt.Sprintf("Page (1-%d)", p.Source.Len()),
+							Style:       discordgo.TextInputShort,
+							Placeholder: strconv.Itoa(p.Source.Len()),
+							Required:    true,
+							MinLength:   1,
+							MaxLength:   len(strconv.Itoa(p.Source.Len())),
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		Errors[ErrorEphemeral](bot, i, err)
+	}
+}
+
+// Stop disables the buttons and stops listening for them.
+func (p *Paginator) Stop() {
+	p.mu.Lock()
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	p.mu.Unlock()
+	p.expire()
+}
+
+func (p *Paginator) expire() {
+	paginatorsMu.Lock()
+	delete(paginators, p.messageID)
+	paginatorsMu.Unlock()
+
+	p.mu.Lock()
+	components := []discordgo.MessageComponent{p.controls(p.page, true)}
+	p.mu.Unlock()
+
+	// the paginator outlives the interaction token, the message is edited through the channel once it expires
+	_, err := editMessageByID(p.bot, p.interaction, p.channelID, p.messageID, &discordgo.WebhookEdit{
+		Components: &components,
+	})
+	if err != nil {
+		InteractionLogger(p.interaction).Error("Error disabling pagination", "message", p.messageID, "err", err)
+	}
+}
+
+func pageCounter(index, total int) string {
+	return fmt.Sprintf("Page %d/%d", index+1, total)
+}
@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var threePages = StaticPages{{Content: "one"}, {Content: "two"}, {Content: "three"}}
+
+// sentResponse is the part of an interaction response the tests look at.
+type sentResponse struct {
+	Type discordgo.InteractionResponseType `json:"type"`
+	Data struct {
+		Content string                 `json:"content"`
+		Flags   discordgo.MessageFlags `json:"flags"`
+	} `json:"data"`
+}
+
+// callback decodes the interaction response sent in a request.
+func callback(t *testing.T, r fakeRequest) sentResponse {
+	t.Helper()
+	var resp sentResponse
+	if err := json.Unmarshal([]byte(r.Body), &resp); err != nil {
+		t.Fatalf("decoding %v: %v", r.Path, err)
+	}
+	return resp
+}
+
+// incidentCount returns how many incidents are stored.
+func incidentCount() int {
+	Incidents.mu.RLock()
+	defer Incidents.mu.RUnlock()
+	return len(Incidents.order)
+}
+
+// componentClick returns a click of the button on the message by the user.
+func componentClick(id Component, messageID, userID string) *discordgo.Interaction {
+	i := testInteraction(time.Now())
+	i.ID = snowflakeAt(time.Now().Add(time.Millisecond))
+	i.Type = discordgo.InteractionMessageComponent
+	i.Data = discordgo.MessageComponentInteractionData{CustomID: string(id)}
+	i.Message = &discordgo.Message{ID: messageID}
+	i.Member = &discordgo.Member{User: &discordgo.User{ID: userID}}
+	return i
+}
+
+func TestPaginateChangesPages(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	p, err := Paginate(session, testInteraction(time.Now()), threePages, PaginatorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+
+	tests := []struct {
+		button Component
+		want   string
+	}{
+		{PaginationNext, "two"},
+		{PaginationNext, "three"},
+		{PaginationNext, "three"},
+		{PaginationFirst, "one"},
+		{PaginationPrevious, "one"},
+		{PaginationLast, "three"},
+	}
+	for _, tt := range tests {
+		HandlePagination(session, componentClick(tt.button, "2000", "4000"))
+		requests := fake.received()
+		resp := callback(t, requests[len(requests)-1])
+		if resp.Type != discordgo.InteractionResponseUpdateMessage || resp.Data.Content != tt.want {
+			t.Errorf("after %v: response %v %q, want an update to %q", tt.button, resp.Type, resp.Data.Content, tt.want)
+		}
+	}
+}
+
+func TestPaginationRejectsOtherUsersAndUnknownMessages(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	p, err := Paginate(session, testInteraction(time.Now()), threePages, PaginatorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+
+	tests := []struct {
+		name  string
+		click *discordgo.Interaction
+		want  string
+	}{
+		{"other user", componentClick(PaginationNext, "2000", "4001"), Message(nil, MessagePaginationNotOwner, nil)},
+		{"unknown message", componentClick(PaginationNext, "2001", "4000"), Message(nil, MessagePaginationExpired, nil)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			incidents := incidentCount()
+			HandlePagination(session, tt.click)
+			requests := fake.received()
+			resp := callback(t, requests[len(requests)-1])
+			if resp.Data.Flags != discordgo.MessageFlagsEphemeral || resp.Data.Content != tt.want {
+				t.Errorf("response %q (flags %v), want the ephemeral message %q", resp.Data.Content, resp.Data.Flags, tt.want)
+			}
+			if got := incidentCount(); got != incidents {
+				t.Errorf("%v incidents were created", got-incidents)
+			}
+		})
+	}
+}
+
+func TestPaginationModalValidatesPage(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	p, err := Paginate(session, testInteraction(time.Now()), threePages, PaginatorOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Stop()
+
+	submit := func(value string) sentResponse {
+		i := componentClick(PaginationJumpModal, "2000", "4000")
+		i.Type = discordgo.InteractionModalSubmit
+		i.Data = discordgo.ModalSubmitInteractionData{
+			CustomID: string(PaginationJumpModal),
+			Components: []discordgo.MessageComponent{&discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+				&discordgo.TextInput{CustomID: string(paginationPageInput), Value: value},
+			}}},
+		}
+		HandlePaginationModal(session, i)
+		requests := fake.received()
+		return callback(t, requests[len(requests)-1])
+	}
+
+	if resp := submit(" 3 "); resp.Data.Content != "three" {
+		t.Errorf("jumping to page 3 showed %q", resp.Data.Content)
+	}
+	for _, value := range []string{"0", "4", "two", ""} {
+		resp := submit(value)
+		if resp.Data.Flags != discordgo.MessageFlagsEphemeral || !strings.Contains(resp.Data.Content, "between 1 and 3") {
+			t.Errorf("jumping to %q responded %q, want the invalid page message", value, resp.Data.Content)
+		}
+	}
+}
+
+func TestPaginateTimeoutDisablesButtons(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	_, err := Paginate(session, testInteraction(time.Now()), threePages, PaginatorOptions{Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		requests := fake.received()
+		last := requests[len(requests)-1]
+		if last.Method == http.MethodPatch {
+			if last.Path != "/webhooks/1000/interaction-token/messages/2000" || !strings.Contains(last.Body, `"disabled":true`) {
+				t.Errorf("expiry sent %v %v %v, want the buttons disabled through the interaction token", last.Method, last.Path, last.Body)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the buttons were not disabled after the timeout")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	paginatorsMu.Lock()
+	_, ok := paginators["2000"]
+	paginatorsMu.Unlock()
+	if ok {
+		t.Error("the paginator is still registered after it expired")
+	}
+}
+
+func TestPaginatorExpiresAfterTokenExpired(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	p := &Paginator{
+		Source:      threePages,
+		bot:         session,
+		interaction: testInteraction(time.Now().Add(-time.Hour)),
+		channelID:   "3000",
+		messageID:   "2000",
+	}
+	p.Stop()
+
+	requests := fake.received()
+	if len(requests) == 0 {
+		t.Fatal("no request was sent")
+	}
+	last := requests[len(requests)-1]
+	if last.Method != http.MethodPatch || last.Path != "/channels/3000/messages/2000" || !strings.Contains(last.Body, `"disabled":true`) {
+		t.Errorf("expiry sent %v %v %v, want the buttons disabled through the channel", last.Method, last.Path, last.Body)
+	}
+}
+
+func TestLazyPagesLoadsEachPageOnce(t *testing.T) {
+	loads := make(map[int]int)
+	pages := LazyPages(3, func(index int) (Page, error) {
+		loads[index]++
+		return Page{Content: threePages[index].Content}, nil
+	})
+
+	for _, index := range []int{0, 2, 0, 2, 1} {
+		page, err := pages.Page(index)
+		if err != nil || page.Content != threePages[index].Content {
+			t.Errorf("Page(%v) = %q, %v", index, page.Content, err)
+		}
+	}
+	for index, n := range loads {
+		if n != 1 {
+			t.Errorf("page %v was loaded %v times", index, n)
+		}
+	}
+}
+
+func TestStaticPagesOutOfRange(t *testing.T) {
+	for _, index := range []int{-1, 3} {
+		if _, err := threePages.Page(index); err == nil {
+			t.Errorf("Page(%v) succeeded", index)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"bytes"
+	"expvar"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const redacted = "[REDACTED]"
+
+// RedactionCount counts the redactions made, by detector name. It is published through expvar as "redactions",
+// which the bot serves at /debug/vars when metrics_addr is set.
+var RedactionCount = expvar.NewMap("redactions")
+
+type detector struct {
+	name    string
+	pattern *regexp.Regexp
+	// group is the submatch to replace, 0 replaces the whole match.
+	group int
+	// secret reports whether the replaced text is a secret, nil means it always is.
+	secret func(string) bool
+}
+
+// Redactor removes secrets from text. It replaces every registered secret and everything matched by its detectors.
+type Redactor struct {
+	mu        sync.RWMutex
+	secrets   []string
+	detectors []detector
+}
+
+// NewRedactor returns a Redactor with detectors for Discord tokens, webhook URLs and common API key formats.
+func NewRedactor() *Redactor {
+	return &Redactor{
+		detectors: []detector{
+			{name: "discord_token", pattern: regexp.MustCompile(`\b[A-Za-z\d_-]{23,28}\.[A-Za-z\d_-]{6,7}\.[A-Za-z\d_-]{27,40}\b`)},
+			{name: "webhook_url", pattern: regexp.MustCompile(`discord(?:app)?\.com/api(?:/v\d+)?/webhooks/\d+/([A-Za-z\d_-]+)`), group: 1},
+			{name: "authorization", pattern: regexp.MustCompile(`(?i)\bauthorization["']?\s*[:=]\s*["']?(?:(?:bearer|bot|basic)\s+)?([A-Za-z\d_\-.=+/~]{8,})`), group: 1},
+			// prose like "bearer bonds" is common, so only strings with digits count
+			{name: "bearer", pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z\d_\-.=+/~]{20,})`), group: 1, secret: hasDigit},
+			{name: "api_key", pattern: regexp.MustCompile(`(?i)\b(?:api[_-]?key|access[_-]?token|secret|password|auth[_-]?token)\b["']?\s*[:=]\s*["']?([^\s"',;&]{8,})`), group: 1},
+			{name: "openai_key", pattern: regexp.MustCompile(`\bsk-[A-Za-z\d_-]{20,}\b`)},
+		},
+	}
+}
+
+// AddSecret registers a secret to be redacted wherever it appears. Empty secrets are ignored.
+func (r *Redactor) AddSecret(secret string) {
+	if secret == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.secrets {
+		if s == secret {
+			return
+		}
+	}
+	r.secrets = append(r.secrets, secret)
+}
+
+// Redact returns s with every secret replaced by [REDACTED].
+func (r *Redactor) Redact(s string) string {
+	if s == "" {
+		return s
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, secret := range r.secrets {
+		if n := strings.Count(s, secret); n > 0 {
+			s = strings.ReplaceAll(s, secret, redacted)
+			RedactionCount.Add("secret", int64(n))
+		}
+	}
+
+	for _, d := range r.detectors {
+		s = d.pattern.ReplaceAllStringFunc(s, func(match string) string {
+			if d.group == 0 {
+				if d.secret != nil && !d.secret(match) {
+					return match
+				}
+				RedactionCount.Add(d.name, 1)
+				return redacted
+			}
+			sub := d.pattern.FindStringSubmatchIndex(match)
+			start, end := sub[2*d.group], sub[2*d.group+1]
+			if start < 0 || match[start:end] == redacted || d.secret != nil && !d.secret(match[start:end]) {
+				return match
+			}
+			RedactionCount.Add(d.name, 1)
+			return match[:start] + redacted + match[end:]
+		})
+	}
+	return s
+}
+
+func hasDigit(s string) bool {
+	return strings.ContainsAny(s, "0123456789")
+}
+
+// DefaultRedactor is used for every outbound message and log line.
+var DefaultRedactor = NewRedactor()
+
+// Redact removes secrets from s using DefaultRedactor.
+func Redact(s string) string {
+	return DefaultRedactor.Redact(s)
+}
+
+// AddSecret registers a secret with DefaultRedactor.
+func AddSecret(secret string) {
+	DefaultRedactor.AddSecret(secret)
+}
+
+// RedactingWriter redacts everything written to w. Use it as the output of loggers.
+func RedactingWriter(w io.Writer) io.Writer {
+	return redactingWriter{w}
+}
+
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (w redactingWriter) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, Redact(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// RedactingTransport redacts the JSON payload of every request sent through next, including the payload_json
+// of file uploads. Headers are left untouched so that authentication keeps working.
+func RedactingTransport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return redactingTransport{next}
+}
+
+type redactingTransport struct {
+	next http.RoundTripper
+}
+
+func (t redactingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return t.next.RoundTrip(req)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return t.next.RoundTrip(req)
+	}
+
+	var body []byte
+	switch {
+	case mediaType == "application/json":
+		raw, err := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		body = []byte(Redact(string(raw)))
+	case mediaType == "multipart/form-data" && params["boundary"] != "":
+		body, err = redactMultipart(req.Body, params["boundary"])
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return t.next.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	return t.next.RoundTrip(req)
+}
+
+// redactMultipart rewrites a multipart body with the same boundary, redacting only the payload_json part
+// so that uploaded files are never modified.
+func redactMultipart(r io.Reader, boundary string) ([]byte, error) {
+	reader := multipart.NewReader(r, boundary)
+	var out bytes.Buffer
+	writer := multipart.NewWriter(&out)
+	if err := writer.SetBoundary(boundary); err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		content, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+		if part.FormName() == "payload_json" {
+			content = []byte(Redact(string(content)))
+		}
+
+		w, err := writer.CreatePart(part.Header)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(content); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
@@ -21,7 +21,7 @@ const (
 	EditInteractionResponse // MsgReturnType Edit the interaction response message
 
 	ephemeralResponding // NewResponseType Respond with an ephemeral message saying "Bot is responding..."
-	ephemeralContent    // MsgResponseType Respond with an ephemeral message with the provided content
+	EphemeralContent    // MsgResponseType Respond with an ephemeral message with the provided content
 
 	HelloResponse // newResponseType Respond with a message saying "Hey there! Congratulations, you just executed your first slash command"
 )
@@ -34,7 +34,7 @@ type editResponseType func(bot *discordgo.Session, i *discordgo.Interaction, mes
 
 var Responses = map[ResponseType]any{
 	ThinkResponse: NewResponseType(func(bot *discordgo.Session, i *discordgo.InteractionCreate) {
-		err := bot.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		err := respond(bot, i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 		})
 		if err != nil {
@@ -42,7 +42,7 @@ var Responses = map[ResponseType]any{
 		}
 	}),
 	EphemeralThink: NewResponseType(func(bot *discordgo.Session, i *discordgo.InteractionCreate) {
-		err := bot.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		err := respond(bot, i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
 				Flags: discordgo.MessageFlagsEphemeral,
@@ -52,14 +52,15 @@ var Responses = map[ResponseType]any{
 		}
 	}),
 	pendingResponse: NewResponseType(func(bot *discordgo.Session, i *discordgo.InteractionCreate) {
-		err := bot.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		err := respond(bot, i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
 				// Note: this isn't documented, but you can use that if you want to.
 				// This flag just allows you to create messages visible only for the caller of the command
 				// (user who triggered the command)
 				//Flags:   discordgo.MessageFlagsEphemeral,
-				Content: "Bot is responding...",
+				Content:         Message(i.Interaction, MessageResponding, nil),
+				AllowedMentions: noMentions(),
 			},
 		})
 		if err != nil {
@@ -67,11 +68,16 @@ var Responses = map[ResponseType]any{
 		}
 	}),
 	messageResponse: MsgResponseType(func(bot *discordgo.Session, i *discordgo.Interaction, message ...any) {
-		err := bot.InteractionRespond(i, &discordgo.InteractionResponse{
+		data := &discordgo.InteractionResponseData{}
+		responseEdit(data, message...)
+		if err := checkAttachments(bot, i.GuildID, data.Files); err != nil {
+			Errors[ErrorEphemeral](bot, i, err)
+			return
+		}
+
+		err := respond(bot, i, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				Content: message[0].(string),
-			},
+			Data: data,
 		})
 		if err != nil {
 			Errors[ErrorFollowup](bot, i, err)
@@ -79,11 +85,17 @@ var Responses = map[ResponseType]any{
 	}),
 	followupResponse: MsgReturnType(func(bot *discordgo.Session, i *discordgo.Interaction, message ...any) *discordgo.Message {
 		webhookParams := contentToWebhookParams(message...)
+		if err := checkAttachments(bot, i.GuildID, webhookParams.Files); err != nil {
+			Errors[ErrorFollowup](bot, i, err)
+			return nil
+		}
 
-		msg, err := bot.FollowupMessageCreate(i, true, &webhookParams)
+		msg, err := followupCreate(bot, i, &webhookParams)
 		if err != nil {
 			Errors[ErrorFollowup](bot, i, err)
+			return nil
 		}
+		TrackFollowup(i, msg)
 		return msg
 	}),
 
@@ -93,8 +105,12 @@ var Responses = map[ResponseType]any{
 
 		contentEdit(webhookEdit, message)
 		contentEdit(webhookEdit, content...)
+		if err := checkAttachments(bot, i.GuildID, webhookEdit.Files); err != nil {
+			Errors[ErrorFollowup](bot, i, err)
+			return nil
+		}
 
-		msg, err := bot.FollowupMessageEdit(i, message.Reference().MessageID, webhookEdit)
+		msg, err := editMessageByID(bot, i, message.ChannelID, message.ID, webhookEdit)
 		if err != nil {
 			Errors[ErrorFollowup](bot, i, err)
 		}
@@ -103,11 +119,17 @@ var Responses = map[ResponseType]any{
 
 	ephemeralFollowup: MsgReturnType(func(bot *discordgo.Session, i *discordgo.Interaction, message ...any) *discordgo.Message {
 		webhookParams := contentToWebhookParams(message...)
+		if err := checkAttachments(bot, i.GuildID, webhookParams.Files); err != nil {
+			Errors[ErrorFollowupEphemeral](bot, i, err)
+			return nil
+		}
 
-		msg, err := bot.FollowupMessageCreate(i, true, &webhookParams)
+		msg, err := followupCreate(bot, i, &webhookParams)
 		if err != nil {
 			Errors[ErrorFollowup](bot, i, err)
+			return nil
 		}
+		TrackFollowup(i, msg)
 		return msg
 	}),
 
@@ -117,8 +139,12 @@ var Responses = map[ResponseType]any{
 
 		contentEdit(webhookEdit, message)
 		contentEdit(webhookEdit, content...)
+		if err := checkAttachments(bot, i.GuildID, webhookEdit.Files); err != nil {
+			Errors[ErrorFollowup](bot, i, err)
+			return nil
+		}
 
-		msg, err := bot.FollowupMessageEdit(i, message.Reference().MessageID, webhookEdit)
+		msg, err := editMessageByID(bot, i, message.ChannelID, message.ID, webhookEdit)
 		if err != nil {
 			Errors[ErrorFollowup](bot, i, err)
 		}
@@ -132,8 +158,12 @@ var Responses = map[ResponseType]any{
 		}
 
 		responseEdit(interactionResponse.Data, content...)
+		if err := checkAttachments(bot, i.GuildID, interactionResponse.Data.Files); err != nil {
+			Errors[ErrorEphemeral](bot, i, err)
+			return
+		}
 
-		err := bot.InteractionRespond(i, &interactionResponse)
+		err := respond(bot, i, &interactionResponse)
 		if err != nil {
 			Errors[ErrorFollowupEphemeral](bot, i, err)
 		}
@@ -144,8 +174,12 @@ var Responses = map[ResponseType]any{
 		webhookEdit := webhookFromContents(content...)
 
 		contentEdit(webhookEdit, content...)
+		if err := checkAttachments(bot, i.GuildID, webhookEdit.Files); err != nil {
+			Errors[ErrorFollowupEphemeral](bot, i, err)
+			return nil
+		}
 
-		msg, err := bot.InteractionResponseEdit(i, webhookEdit)
+		msg, err := responseEditRetry(bot, i, webhookEdit)
 		if err != nil {
 			Errors[ErrorEphemeral](bot, i, err)
 		}
@@ -153,40 +187,48 @@ var Responses = map[ResponseType]any{
 	}),
 
 	ephemeralResponding: NewResponseType(func(bot *discordgo.Session, i *discordgo.InteractionCreate) {
-		err := bot.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		err := respond(bot, i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
 				// Note: this isn't documented, but you can use that if you want to.
 				// This flag just allows you to create messages visible only for the caller of the command
 				// (user who triggered the command)
-				Flags:   discordgo.MessageFlagsEphemeral,
-				Content: "Bot is responding...",
+				Flags:           discordgo.MessageFlagsEphemeral,
+				Content:         Message(i.Interaction, MessageResponding, nil),
+				AllowedMentions: noMentions(),
 			},
 		})
 		if err != nil {
 			Errors[ErrorResponse](bot, i.Interaction, err)
 		}
 	}),
-	ephemeralContent: MsgResponseType(func(bot *discordgo.Session, i *discordgo.Interaction, message ...any) {
-		err := bot.InteractionRespond(i, &discordgo.InteractionResponse{
+	EphemeralContent: MsgResponseType(func(bot *discordgo.Session, i *discordgo.Interaction, message ...any) {
+		data := &discordgo.InteractionResponseData{
+			// Note: this isn't documented, but you can use that if you want to.
+			// This flag just allows you to create messages visible only for the caller of the command
+			// (user who triggered the command)
+			Flags: discordgo.MessageFlagsEphemeral,
+		}
+		responseEdit(data, message...)
+		if err := checkAttachments(bot, i.GuildID, data.Files); err != nil {
+			Errors[ErrorEphemeral](bot, i, err)
+			return
+		}
+
+		err := respond(bot, i, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
-			Data: &discordgo.InteractionResponseData{
-				// Note: this isn't documented, but you can use that if you want to.
-				// This flag just allows you to create messages visible only for the caller of the command
-				// (user who triggered the command)
-				Flags:   discordgo.MessageFlagsEphemeral,
-				Content: message[0].(string),
-			},
+			Data: data,
 		})
 		if err != nil {
 			Errors[ErrorFollowup](bot, i, err)
 		}
 	}),
 	HelloResponse: NewResponseType(func(bot *discordgo.Session, i *discordgo.InteractionCreate) {
-		err := bot.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		err := respond(bot, i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "Hey there! Congratulations, you just executed your first slash command",
+				Content:         Message(i.Interaction, MessageHello, nil),
+				AllowedMentions: noMentions(),
 			},
 		})
 		if err != nil {
@@ -210,6 +252,11 @@ func webhookFromContents(content ...any) *discordgo.WebhookEdit {
 
 func contentToWebhookParams(content ...any) discordgo.WebhookParams {
 	webhookParams := discordgo.WebhookParams{}
+	defer func() {
+		if webhookParams.AllowedMentions == nil {
+			webhookParams.AllowedMentions = allowedMentions(content...)
+		}
+	}()
 	for _, m := range content {
 		switch c := m.(type) {
 		case discordgo.WebhookParams:
@@ -220,32 +267,56 @@ func contentToWebhookParams(content ...any) discordgo.WebhookParams {
 			webhookParams.Components = append(webhookParams.Components, c)
 		case discordgo.MessageFlags:
 			webhookParams.Flags = c
+		case *discordgo.MessageEmbed:
+			webhookParams.Embeds = append(webhookParams.Embeds, c)
+		case *EmbedBuilder:
+			webhookParams.Embeds = append(webhookParams.Embeds, c.Build())
+			webhookParams.Files = append(webhookParams.Files, c.Files()...)
+		default:
+			if file, ok := toFile(c); ok {
+				webhookParams.Files = append(webhookParams.Files, file)
+			}
 		}
 	}
 	return webhookParams
 }
 
 func contentEdit(webhookEdit *discordgo.WebhookEdit, messages ...any) {
+	if webhookEdit.AllowedMentions == nil {
+		webhookEdit.AllowedMentions = allowedMentions(messages...)
+	}
 	if len(messages) == 0 {
 		return
 	}
 	var newEmbeds []*discordgo.MessageEmbed
 	var newComponents []discordgo.MessageComponent
+	var newFiles []*discordgo.File
+	var existing []*discordgo.MessageAttachment
 	for _, m := range messages {
 		switch c := m.(type) {
 		case *discordgo.Message:
 			webhookEdit.Content = &c.Content
 			webhookEdit.Embeds = &c.Embeds
 			webhookEdit.Components = &c.Components
+			existing = c.Attachments
 		case string:
 			//log.Println("String content: ", c)
 			webhookEdit.Content = &c
 		case discordgo.MessageEmbed:
 			newEmbeds = append(newEmbeds, &c)
+		case *discordgo.MessageEmbed:
+			newEmbeds = append(newEmbeds, c)
+		case *EmbedBuilder:
+			newEmbeds = append(newEmbeds, c.Build())
+			newFiles = append(newFiles, c.Files()...)
 		case discordgo.MessageComponent:
 			newComponents = append(newComponents, c)
 		case []discordgo.MessageComponent:
 			newComponents = append(newComponents, c...)
+		default:
+			if file, ok := toFile(c); ok {
+				newFiles = append(newFiles, file)
+			}
 		}
 	}
 	if len(newComponents) > 0 {
@@ -254,31 +325,52 @@ func contentEdit(webhookEdit *discordgo.WebhookEdit, messages ...any) {
 	if len(newEmbeds) > 0 {
 		webhookEdit.Embeds = &newEmbeds
 	}
+	if len(newFiles) > 0 {
+		webhookEdit.Files = append(webhookEdit.Files, newFiles...)
+	}
+	if attachments := attachmentsFromContents(newFiles, existing, messages...); attachments != nil {
+		webhookEdit.Attachments = attachments
+	}
 }
 
 func responseEdit(resp *discordgo.InteractionResponseData, messages ...any) {
 	if resp == nil {
 		resp = &discordgo.InteractionResponseData{}
 	}
+	if resp.AllowedMentions == nil {
+		resp.AllowedMentions = allowedMentions(messages...)
+	}
 	if len(messages) == 0 {
 		return
 	}
 	var newEmbeds []*discordgo.MessageEmbed
 	var newComponents []discordgo.MessageComponent
+	var newFiles []*discordgo.File
+	var existing []*discordgo.MessageAttachment
 	for _, m := range messages {
 		switch c := m.(type) {
 		case *discordgo.Message:
 			resp.Content = c.Content
 			resp.Embeds = c.Embeds
 			resp.Components = c.Components
+			existing = c.Attachments
 		case string:
 			resp.Content = c
 		case discordgo.MessageEmbed:
 			newEmbeds = append(newEmbeds, &c)
+		case *discordgo.MessageEmbed:
+			newEmbeds = append(newEmbeds, c)
+		case *EmbedBuilder:
+			newEmbeds = append(newEmbeds, c.Build())
+			newFiles = append(newFiles, c.Files()...)
 		case discordgo.MessageComponent:
 			newComponents = append(newComponents, c)
 		case []discordgo.MessageComponent:
 			newComponents = append(newComponents, c...)
+		default:
+			if file, ok := toFile(c); ok {
+				newFiles = append(newFiles, file)
+			}
 		}
 	}
 	if len(newComponents) > 0 {
@@ -287,6 +379,12 @@ func responseEdit(resp *discordgo.InteractionResponseData, messages ...any) {
 	if len(newEmbeds) > 0 {
 		resp.Embeds = newEmbeds
 	}
+	if len(newFiles) > 0 {
+		resp.Files = append(resp.Files, newFiles...)
+	}
+	if attachments := attachmentsFromContents(newFiles, existing, messages...); attachments != nil {
+		resp.Attachments = attachments
+	}
 }
 func EphemeralFollowup(bot *discordgo.Session, i *discordgo.Interaction, message ...any) {
 	Responses[ephemeralFollowup].(MsgReturnType)(bot, i, message...)
@@ -295,3 +393,16 @@ func EphemeralFollowup(bot *discordgo.Session, i *discordgo.Interaction, message
 func DeleteAboveFollowup(bot *discordgo.Session, i *discordgo.Interaction) {
 	Errors[ErrorFollowupEphemeral](bot, i, "Delete generation", Components[DeleteButton])
 }
+
+// UserID returns the ID of the user who triggered the interaction, both in guilds and in DMs.
+func UserID(i *discordgo.Interaction) string {
+	switch {
+	case i == nil:
+		return ""
+	case i.Member != nil && i.Member.User != nil:
+		return i.Member.User.ID
+	case i.User != nil:
+		return i.User.ID
+	}
+	return ""
+}
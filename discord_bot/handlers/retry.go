@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// RetryPolicy controls how REST calls are retried after transient failures such as 5xx responses,
+// rate limits and network errors. Delays grow exponentially with full jitter and are never shorter
+// than a Retry-After sent by Discord.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+}
+
+// DefaultRetryPolicy is used by every response helper.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    10 * time.Second,
+}
+
+// Retry metrics by operation, published through expvar as "rest_retries" and "rest_failures",
+// which the bot serves at /debug/vars when metrics_addr is set.
+var (
+	RetryCount   = expvar.NewMap("rest_retries")
+	FailureCount = expvar.NewMap("rest_failures")
+)
+
+// retryOptions turn off the retries discordgo does on its own, so that every retry goes through the policy.
+var retryOptions = []discordgo.RequestOption{
+	discordgo.WithRetryOnRatelimit(false),
+	discordgo.WithRestRetries(0),
+}
+
+// retryIdempotent calls fn until it succeeds, fails permanently or the policy gives up.
+// Use it for edits, deletes and reads, which have the same effect no matter how often they're sent.
+func retryIdempotent[T any](i *discordgo.Interaction, op string, files []*discordgo.File, fn func(options ...discordgo.RequestOption) (T, error)) (T, error) {
+	ctx, cancel := interactionContext(i)
+	defer cancel()
+	return retry(ctx, DefaultRetryPolicy, i, op, true, files, fn)
+}
+
+// retrySend is retryIdempotent for calls that create something, such as followups and interaction responses.
+// They're only retried when Discord certainly didn't process the request, so a retry can't duplicate a message.
+func retrySend[T any](i *discordgo.Interaction, op string, files []*discordgo.File, fn func(options ...discordgo.RequestOption) (T, error)) (T, error) {
+	ctx, cancel := interactionContext(i)
+	defer cancel()
+	return retry(ctx, DefaultRetryPolicy, i, op, false, files, fn)
+}
+
+// interactionContext returns a context that ends when the interaction's token expires, as retrying after that
+// can only fail.
+func interactionContext(i *discordgo.Interaction) (context.Context, context.CancelFunc) {
+	if i == nil || i.Token == "" {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), interactionCreated(i).Add(interactionTokenLifetime))
+}
+
+// respond is InteractionRespond through retrySend.
+func respond(bot *discordgo.Session, i *discordgo.Interaction, resp *discordgo.InteractionResponse) error {
+	var files []*discordgo.File
+	if resp.Data != nil {
+		files = resp.Data.Files
+	}
+	_, err := retrySend(i, "interaction_respond", files, func(options ...discordgo.RequestOption) (struct{}, error) {
+		return struct{}{}, bot.InteractionRespond(i, resp, options...)
+	})
+	return err
+}
+
+// followupCreate is FollowupMessageCreate through retrySend.
+func followupCreate(bot *discordgo.Session, i *discordgo.Interaction, params *discordgo.WebhookParams) (*discordgo.Message, error) {
+	return retrySend(i, "followup_create", params.Files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return bot.FollowupMessageCreate(i, true, params, options...)
+	})
+}
+
+// responseEditRetry is InteractionResponseEdit through retryIdempotent.
+func responseEditRetry(bot *discordgo.Session, i *discordgo.Interaction, edit *discordgo.WebhookEdit) (*discordgo.Message, error) {
+	return retryIdempotent(i, "interaction_response_edit", edit.Files, func(options ...discordgo.RequestOption) (*discordgo.Message, error) {
+		return bot.InteractionResponseEdit(i, edit, options...)
+	})
+}
+
+// retry runs fn under the policy, see retryIdempotent and retrySend. It stops waiting between attempts when ctx ends,
+// and gives up instead of waiting past its deadline.
+func retry[T any](ctx context.Context, p RetryPolicy, i *discordgo.Interaction, op string, idempotent bool, files []*discordgo.File, fn func(options ...discordgo.RequestOption) (T, error)) (T, error) {
+	var zero T
+
+	rewind, err := rewindableFiles(files)
+	if err != nil {
+		FailureCount.Add(op, 1)
+		return zero, err
+	}
+
+	options := append(slices.Clip(retryOptions), discordgo.WithContext(ctx))
+	for attempt := 1; ; attempt++ {
+		rewind()
+		result, err := fn(options...)
+		if err == nil {
+			return result, nil
+		}
+
+		retryable, processed, retryAfter := transient(err)
+		if !retryable || (processed && !idempotent) || attempt >= p.MaxAttempts {
+			FailureCount.Add(op, 1)
+			return zero, err
+		}
+
+		wait := max(p.backoff(attempt), retryAfter)
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			FailureCount.Add(op, 1)
+			return zero, err
+		}
+
+		RetryCount.Add(op, 1)
+		InteractionLogger(i).Warn("Retrying request", "op", op, "in", wait.Round(time.Millisecond), "attempt", attempt+1, "max_attempts", p.MaxAttempts, "err", err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			FailureCount.Add(op, 1)
+			return zero, errors.Join(err, ctx.Err())
+		}
+	}
+}
+
+// backoff returns a random delay up to BaseDelay*2^(attempt-1), capped at MaxDelay.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.MaxDelay
+	if shift := attempt - 1; shift < 32 && p.BaseDelay<<shift < p.MaxDelay {
+		ceiling = p.BaseDelay << shift
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// transient reports whether err may go away when retried, whether Discord may have processed
+// the request anyway, and how long Discord asked to wait before retrying.
+func transient(err error) (retryable, processed bool, retryAfter time.Duration) {
+	var rateLimit *discordgo.RateLimitError
+	if errors.As(err, &rateLimit) {
+		return true, false, rateLimit.RetryAfter
+	}
+
+	var restErr *discordgo.RESTError
+	if errors.As(err, &restErr) && restErr.Response != nil {
+		status := restErr.Response.StatusCode
+		switch {
+		case status == http.StatusTooManyRequests:
+			return true, false, parseRetryAfter(restErr.Response.Header)
+		case status >= 500:
+			return true, true, parseRetryAfter(restErr.Response.Header)
+		}
+		return false, true, 0
+	}
+
+	// discordgo reports a 502 as a plain error once its own retries are exhausted
+	if strings.HasPrefix(err.Error(), "Exceeded Max retries HTTP 502") {
+		return true, true, 0
+	}
+
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true, false, 0
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true, true, 0
+	}
+	return false, false, 0
+}
+
+// parseRetryAfter reads the Retry-After header, which Discord sends in seconds.
+func parseRetryAfter(header http.Header) time.Duration {
+	seconds, err := strconv.ParseFloat(header.Get("Retry-After"), 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// rewindableFiles buffers the files so that every attempt uploads them from the start.
+// The returned func resets the readers before an attempt.
+func rewindableFiles(files []*discordgo.File) (func(), error) {
+	if len(files) == 0 {
+		return func() {}, nil
+	}
+
+	contents := make([][]byte, len(files))
+	for n, file := range files {
+		if file == nil || file.Reader == nil {
+			continue
+		}
+		data, err := io.ReadAll(file.Reader)
+		if err != nil {
+			return nil, err
+		}
+		contents[n] = data
+	}
+	return func() {
+		for n, file := range files {
+			if contents[n] != nil {
+				file.Reader = bytes.NewReader(contents[n])
+			}
+		}
+	}, nil
+}
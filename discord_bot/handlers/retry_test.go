@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// serverError is a 500 response, which is retried.
+var serverError = &discordgo.RESTError{Response: &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}}}
+
+func TestRetryStopsWaitingWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	attempts := 0
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := retry(ctx, policy, nil, "test", true, nil, func(...discordgo.RequestOption) (struct{}, error) {
+		attempts++
+		return struct{}{}, serverError
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("retry() = %v, want it to be cancelled", err)
+	}
+	if !errors.Is(err, serverError) {
+		t.Errorf("retry() = %v, want the last attempt's error", err)
+	}
+	if attempts != 1 {
+		t.Errorf("attempts = %v, want 1", attempts)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("retry() returned after %v, cancellation was ignored", elapsed)
+	}
+}
+
+func TestRetryGivesUpBeforeDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	policy := RetryPolicy{MaxAttempts: 5, BaseDelay: time.Hour, MaxDelay: time.Hour}
+
+	attempts := 0
+	_, err := retry(ctx, policy, nil, "test", true, nil, func(...discordgo.RequestOption) (struct{}, error) {
+		attempts++
+		if attempts == 1 {
+			// a retry-after longer than the deadline
+			return struct{}{}, &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: time.Minute}}}
+		}
+		return struct{}{}, nil
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("retry() = %v after %v attempts, want it to give up after 1", err, attempts)
+	}
+}
+
+func TestRetryRetriesTransientErrors(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	attempts := 0
+	got, err := retry(context.Background(), policy, nil, "test", true, nil, func(...discordgo.RequestOption) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, serverError
+		}
+		return 42, nil
+	})
+	if err != nil || got != 42 || attempts != 3 {
+		t.Errorf("retry() = %v, %v after %v attempts, want 42 after 3", got, err, attempts)
+	}
+}
+
+func TestTransient(t *testing.T) {
+	restError := func(status int, retryAfter string) error {
+		header := http.Header{}
+		if retryAfter != "" {
+			header.Set("Retry-After", retryAfter)
+		}
+		return &discordgo.RESTError{Response: &http.Response{StatusCode: status, Header: header}}
+	}
+
+	tests := []struct {
+		name           string
+		err            error
+		wantRetryable  bool
+		wantProcessed  bool
+		wantRetryAfter time.Duration
+	}{
+		{"rate limit", &discordgo.RateLimitError{RateLimit: &discordgo.RateLimit{TooManyRequests: &discordgo.TooManyRequests{RetryAfter: 2 * time.Second}}}, true, false, 2 * time.Second},
+		{"429", restError(http.StatusTooManyRequests, "1.5"), true, false, 1500 * time.Millisecond},
+		{"500", restError(http.StatusInternalServerError, ""), true, true, 0},
+		{"503 with retry after", restError(http.StatusServiceUnavailable, "3"), true, true, 3 * time.Second},
+		{"400", restError(http.StatusBadRequest, ""), false, true, 0},
+		{"404", restError(http.StatusNotFound, ""), false, true, 0},
+		{"wrapped 500", fmt.Errorf("editing: %w", restError(http.StatusBadGateway, "")), true, true, 0},
+		{"discordgo 502", errors.New("Exceeded Max retries HTTP 502 Bad Gateway"), true, true, 0},
+		{"dial", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true, false, 0},
+		{"read", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset")}, true, true, 0},
+		{"unexpected EOF", io.ErrUnexpectedEOF, true, true, 0},
+		{"other", errors.New("invalid form body"), false, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryable, processed, retryAfter := transient(tt.err)
+			if retryable != tt.wantRetryable || processed != tt.wantProcessed || retryAfter != tt.wantRetryAfter {
+				t.Errorf("transient() = %v, %v, %v, want %v, %v, %v", retryable, processed, retryAfter, tt.wantRetryable, tt.wantProcessed, tt.wantRetryAfter)
+			}
+		})
+	}
+}
+
+func TestRetrySendDoesNotRepeatProcessedRequests(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	attempts := 0
+	_, err := retry(context.Background(), policy, nil, "test", false, nil, func(...discordgo.RequestOption) (struct{}, error) {
+		attempts++
+		return struct{}{}, serverError
+	})
+	if err == nil || attempts != 1 {
+		t.Errorf("retry() = %v after %v attempts, want a 500 to fail a send right away", err, attempts)
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Progress reports how far along a task is, from 0 to 1, with an optional status line.
+type Progress func(fraction float64, status string)
+
+// TaskFunc is a long-running task. It must return promptly once ctx is cancelled.
+type TaskFunc func(ctx context.Context, progress Progress) error
+
+// DefaultProgressInterval is the minimum time between two progress edits of the same message.
+const DefaultProgressInterval = 2 * time.Second
+
+const progressBarWidth = 20
+
+var taskButtons = regexp.MustCompile(`^(` + string(Cancel) + `|` + string(Interrupt) + `)$`)
+
+// TaskRunner runs tasks behind deferred interaction responses, at most a fixed number at a time.
+// Tasks waiting for a slot show a Cancel button, running tasks show an Interrupt button.
+type TaskRunner struct {
+	// Interval throttles the progress edits, defaults to DefaultProgressInterval.
+	Interval time.Duration
+
+	slots chan struct{}
+}
+
+// NewTaskRunner returns a TaskRunner that runs up to concurrency tasks at once.
+func NewTaskRunner(concurrency int) *TaskRunner {
+	return &TaskRunner{
+		Interval: DefaultProgressInterval,
+		slots:    make(chan struct{}, max(concurrency, 1)),
+	}
+}
+
+// ErrTaskInterrupted is returned by Run when the user interrupted a running task.
+var ErrTaskInterrupted = fmt.Errorf("task interrupted: %w", context.Canceled)
+
+// Run runs fn and reports its progress by editing the response to i, which must already be deferred.
+// It blocks until fn returns and returns its error.
+//
+// While the task waits for a slot the user can Cancel it, fn never runs and Run returns context.Canceled.
+// Once it's running the user can Interrupt it, fn's context is cancelled, the last progress is kept on the message
+// and Run returns ErrTaskInterrupted. Clicks on a button that no longer applies are acknowledged and ignored.
+func (r *TaskRunner) Run(ctx context.Context, bot *discordgo.Session, i *discordgo.Interaction, title string, fn TaskFunc) error {
+	edits := NewEditScheduler(bot, r.Interval)
+	edit := func(content string, row discordgo.MessageComponent) *EditFuture {
+		return edits.EditInteractionResponse(i, title+"\n"+content, []discordgo.MessageComponent{row})
+	}
+
+	msg, err := edit("Waiting in queue...", Components[Cancel]).Wait(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	collector := NewCollector(bot, CollectorOptions{
+		MessageID: msg.ID,
+		UserID:    UserID(i),
+		CustomID:  taskButtons,
+	})
+	defer collector.Stop()
+
+	var state struct {
+		sync.Mutex
+		started     bool
+		interrupted bool
+		progress    string // the last rendered progress
+	}
+	// every click is acknowledged until the collector stops, not only the first one
+	go func() {
+		for {
+			button, err := collector.Next(context.Background())
+			if err != nil {
+				return
+			}
+			err = respond(bot, button, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseDeferredMessageUpdate,
+			})
+			if err != nil {
+				InteractionLogger(button).Warn("Error acknowledging task button", "err", err)
+			}
+
+			state.Lock()
+			switch Component(button.MessageComponentData().CustomID) {
+			case Cancel:
+				if !state.started {
+					cancel()
+				}
+			case Interrupt:
+				if state.started {
+					state.interrupted = true
+					cancel()
+				}
+			}
+			state.Unlock()
+		}
+	}()
+
+	// the final edit must land even though ctx may be cancelled by then
+	finish := func(content string, row discordgo.MessageComponent) {
+		if _, err := edit(content, row).Wait(context.Background()); err != nil {
+			InteractionLogger(i).Error("Error updating task", "message", msg.ID, "err", err)
+		}
+	}
+
+	select {
+	case r.slots <- struct{}{}:
+		defer func() { <-r.slots }()
+	case <-ctx.Done():
+	}
+	// a slot may be picked even though the task was cancelled at the same time
+	state.Lock()
+	state.started = ctx.Err() == nil
+	started := state.started
+	state.Unlock()
+	if !started {
+		finish("Cancelled before it started", Components[CancelDisabled])
+		return ctx.Err()
+	}
+
+	edit("Starting...", Components[Interrupt])
+
+	reporter := &progressReporter{started: time.Now()}
+	err = fn(ctx, func(fraction float64, status string) {
+		progress := reporter.render(min(max(fraction, 0), 1), status)
+		state.Lock()
+		state.progress = progress
+		state.Unlock()
+		edit(progress, Components[Interrupt])
+	})
+
+	state.Lock()
+	interrupted, last := state.interrupted, state.progress
+	state.Unlock()
+
+	switch {
+	case interrupted:
+		err = ErrTaskInterrupted
+		finish(strings.TrimSpace(last+"\nInterrupted"), Components[InterruptDisabled])
+	case err != nil:
+		finish("Failed", Components[InterruptDisabled])
+	default:
+		finish(progressBar(1)+"\nDone in "+reporter.elapsed().Round(time.Second).String(), Components[InterruptDisabled])
+	}
+	return err
+}
+
+// progressReporter renders the progress of a task.
+type progressReporter struct {
+	started time.Time
+}
+
+func (p *progressReporter) elapsed() time.Duration {
+	return time.Since(p.started)
+}
+
+func (p *progressReporter) render(fraction float64, status string) string {
+	lines := []string{progressBar(fraction)}
+	if eta, ok := estimate(p.elapsed(), fraction); ok {
+		lines[0] += fmt.Sprintf(" ETA %v", eta.Round(time.Second))
+	}
+	if status != "" {
+		lines = append(lines, status)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// estimate returns the time remaining assuming the task progresses at a constant rate.
+func estimate(elapsed time.Duration, fraction float64) (time.Duration, bool) {
+	if fraction <= 0 || fraction >= 1 {
+		return 0, false
+	}
+	remaining := float64(elapsed) / fraction * (1 - fraction)
+	if math.IsInf(remaining, 0) || math.IsNaN(remaining) {
+		return 0, false
+	}
+	return time.Duration(remaining), true
+}
+
+func progressBar(fraction float64) string {
+	filled := int(math.Round(fraction * progressBarWidth))
+	return fmt.Sprintf("`%v%v` %3.0f%%",
+		strings.Repeat("█", filled),
+		strings.Repeat("░", progressBarWidth-filled),
+		fraction*100,
+	)
+}
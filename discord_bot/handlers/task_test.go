@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// taskEdits returns the contents of the edits to the task's message.
+func taskEdits(fake *fakeDiscord) []string {
+	var edits []string
+	for _, r := range fake.received() {
+		if r.Method == http.MethodPatch && r.Path == "/webhooks/1000/interaction-token/messages/@original" {
+			edits = append(edits, fakeMessage(r).Content)
+		}
+	}
+	return edits
+}
+
+// acknowledged reports whether the click was responded to.
+func acknowledged(fake *fakeDiscord, click *discordgo.Interaction) bool {
+	for _, r := range fake.received() {
+		if r.Method == http.MethodPost && strings.HasPrefix(r.Path, "/interactions/"+click.ID+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// eventually fails the test if cond doesn't become true within a second.
+func eventually(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %v", what)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// collecting reports whether any collector is registered.
+func collecting() bool {
+	collectorsMu.RLock()
+	defer collectorsMu.RUnlock()
+	return len(collectors) > 0
+}
+
+// runTask runs fn on r in the background and returns the channel Run's error is sent on.
+func runTask(session *discordgo.Session, r *TaskRunner, fn TaskFunc) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Run(context.Background(), session, testInteraction(time.Now()), "Task", fn)
+	}()
+	return done
+}
+
+func TestTaskRunnerThrottlesProgress(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	r := NewTaskRunner(1)
+	r.Interval = 50 * time.Millisecond
+
+	err := <-runTask(session, r, func(ctx context.Context, progress Progress) error {
+		for n := 1; n <= 100; n++ {
+			progress(float64(n)/100, "step")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Run() = %v", err)
+	}
+
+	edits := taskEdits(fake)
+	if len(edits) > 5 {
+		t.Errorf("sent %v edits for 100 progress reports, want them throttled", len(edits))
+	}
+	if last := edits[len(edits)-1]; !strings.Contains(last, "100%") || !strings.Contains(last, "Done in") {
+		t.Errorf("the last edit is %q, want the task done", last)
+	}
+}
+
+func TestTaskRunnerCancelWhileQueued(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	r := NewTaskRunner(1)
+	r.Interval = time.Millisecond
+	r.slots <- struct{}{} // another task is running
+
+	ran := false
+	done := runTask(session, r, func(ctx context.Context, progress Progress) error {
+		ran = true
+		return nil
+	})
+	eventually(t, "the task to be queued", collecting)
+
+	interrupt := componentClick(Interrupt, "2000", "4000")
+	if !Collect(session, interrupt) {
+		t.Fatal("the Interrupt click wasn't collected")
+	}
+	eventually(t, "the Interrupt click to be acknowledged", func() bool { return acknowledged(fake, interrupt) })
+	select {
+	case err := <-done:
+		t.Fatalf("Interrupt ended a queued task with %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel := componentClick(Cancel, "2000", "4000")
+	if !Collect(session, cancel) {
+		t.Fatal("the Cancel click wasn't collected")
+	}
+	if err := <-done; !errors.Is(err, context.Canceled) || errors.Is(err, ErrTaskInterrupted) {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	if ran {
+		t.Error("the cancelled task ran")
+	}
+	edits := taskEdits(fake)
+	if last := edits[len(edits)-1]; !strings.Contains(last, "Cancelled before it started") {
+		t.Errorf("the last edit is %q, want the task cancelled", last)
+	}
+	if len(r.slots) != 1 {
+		t.Error("the cancelled task changed the slots")
+	}
+}
+
+func TestTaskRunnerInterruptKeepsAcknowledging(t *testing.T) {
+	fake, session := newFakeDiscord(t)
+	r := NewTaskRunner(1)
+	r.Interval = time.Millisecond
+
+	interrupted := make(chan struct{})
+	release := make(chan struct{})
+	done := runTask(session, r, func(ctx context.Context, progress Progress) error {
+		progress(0.5, "halfway")
+		<-ctx.Done()
+		close(interrupted)
+		<-release
+		return ctx.Err()
+	})
+	eventually(t, "the progress", func() bool {
+		edits := taskEdits(fake)
+		return len(edits) > 0 && strings.Contains(edits[len(edits)-1], "halfway")
+	})
+
+	// Cancel no longer applies once the task is running
+	cancel := componentClick(Cancel, "2000", "4000")
+	Collect(session, cancel)
+	eventually(t, "the Cancel click to be acknowledged", func() bool { return acknowledged(fake, cancel) })
+	select {
+	case <-interrupted:
+		t.Fatal("Cancel interrupted a running task")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	Collect(session, componentClick(Interrupt, "2000", "4000"))
+	<-interrupted
+
+	again := componentClick(Interrupt, "2000", "4000")
+	if !Collect(session, again) {
+		t.Fatal("a click after the interrupt wasn't collected")
+	}
+	eventually(t, "the second click to be acknowledged", func() bool { return acknowledged(fake, again) })
+
+	close(release)
+	if err := <-done; !errors.Is(err, ErrTaskInterrupted) {
+		t.Errorf("Run() = %v, want ErrTaskInterrupted", err)
+	}
+	edits := taskEdits(fake)
+	if last := edits[len(edits)-1]; !strings.Contains(last, "halfway") || !strings.HasSuffix(last, "Interrupted") {
+		t.Errorf("the last edit is %q, want the last progress and Interrupted", last)
+	}
+	if Collect(session, componentClick(Interrupt, "2000", "4000")) {
+		t.Error("the collector is still registered after Run returned")
+	}
+}
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		elapsed  time.Duration
+		fraction float64
+		want     time.Duration
+		ok       bool
+	}{
+		{10 * time.Second, 0.5, 10 * time.Second, true},
+		{10 * time.Second, 0.25, 30 * time.Second, true},
+		{30 * time.Second, 0.75, 10 * time.Second, true},
+		{0, 0.5, 0, true},
+		{10 * time.Second, 0, 0, false},
+		{10 * time.Second, -1, 0, false},
+		{10 * time.Second, 1, 0, false},
+		{10 * time.Second, 1e-300, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := estimate(tt.elapsed, tt.fraction)
+		if ok != tt.ok || (ok && (got-tt.want).Abs() > time.Millisecond) {
+			t.Errorf("estimate(%v, %v) = %v, %v, want %v, %v", tt.elapsed, tt.fraction, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Message names of the response catalog.
+const (
+	MessageHello                 = "hello"
+	MessageResponding            = "responding"
+	MessagePaginationNotOwner    = "pagination_not_owner"
+	MessagePaginationExpired     = "pagination_expired"
+	MessagePaginationInvalidPage = "pagination_invalid_page"
+	MessageCollectorNotOwner     = "collector_not_owner"
+	MessageConfirmDelete         = "confirm_delete"
+	MessageRestarting            = "restarting"
+)
+
+// templateSamples holds sample data for every message the bot uses, used by Catalog.Validate.
+var templateSamples = map[string]any{
+	MessageHello:                 nil,
+	MessageResponding:            nil,
+	MessagePaginationNotOwner:    nil,
+	MessagePaginationExpired:     nil,
+	MessagePaginationInvalidPage: map[string]any{"Pages": 10},
+	MessageCollectorNotOwner:     nil,
+	MessageConfirmDelete:         nil,
+	MessageRestarting:            nil,
+}
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+const templateExt = ".tmpl"
+
+// Catalog holds the response templates, loaded from a directory laid out as:
+//
+//	<name>.tmpl                             default message
+//	<name>.<locale>.tmpl                    message for a locale, e.g. hello.es-ES.tmpl or hello.fr.tmpl
+//	guilds/<guild id>/<name>[.<locale>].tmpl overrides for a guild
+//
+// Messages missing from the directory fall back to the defaults embedded in the binary.
+type Catalog struct {
+	// templates maps guild ID ("" for all guilds) to message name and locale ("" for the default).
+	templates map[string]map[string]map[string]*template.Template
+}
+
+var (
+	catalog   = mustLoadDefaultCatalog()
+	catalogMu sync.RWMutex
+)
+
+// LoadCatalog loads the embedded default templates, then the templates in dir on top of them.
+// An empty dir only loads the defaults.
+func LoadCatalog(dir string) (*Catalog, error) {
+	c := &Catalog{templates: make(map[string]map[string]map[string]*template.Template)}
+
+	defaults, err := fs.Sub(defaultTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if err := c.load(defaults, ""); err != nil {
+		return nil, err
+	}
+
+	if dir == "" {
+		return c, nil
+	}
+	if err := c.load(os.DirFS(dir), ""); err != nil {
+		return nil, err
+	}
+
+	guilds, err := fs.ReadDir(os.DirFS(dir), "guilds")
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	for _, guild := range guilds {
+		if !guild.IsDir() {
+			continue
+		}
+		guildFS, err := fs.Sub(os.DirFS(dir), path.Join("guilds", guild.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if err := c.load(guildFS, guild.Name()); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func mustLoadDefaultCatalog() *Catalog {
+	c, err := LoadCatalog("")
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded response templates: %v", err))
+	}
+	return c
+}
+
+// load parses the templates at the root of fsys into the guild's set.
+func (c *Catalog) load(fsys fs.FS, guildID string) error {
+	files, err := fs.Glob(fsys, "*"+templateExt)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	for _, file := range files {
+		name, locale, _ := strings.Cut(strings.TrimSuffix(file, templateExt), ".")
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%v: %v", file, err))
+			continue
+		}
+		tmpl, err := template.New(file).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+
+		if c.templates[guildID] == nil {
+			c.templates[guildID] = make(map[string]map[string]*template.Template)
+		}
+		if c.templates[guildID][name] == nil {
+			c.templates[guildID][name] = make(map[string]*template.Template)
+		}
+		c.templates[guildID][name][locale] = tmpl
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("could not parse response templates:\n%v", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// lookup returns the most specific template for the message, from the guild's locale down to the default message.
+func (c *Catalog) lookup(name, guildID string, locale discordgo.Locale) (*template.Template, bool) {
+	locales := []string{string(locale)}
+	if language, _, ok := strings.Cut(string(locale), "-"); ok {
+		locales = append(locales, language)
+	}
+	locales = append(locales, "")
+
+	for _, guild := range []string{guildID, ""} {
+		messages, ok := c.templates[guild]
+		if !ok {
+			continue
+		}
+		for _, l := range locales {
+			if tmpl, ok := messages[name][l]; ok {
+				return tmpl, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Render renders the message for the guild and locale.
+func (c *Catalog) Render(name, guildID string, locale discordgo.Locale, data any) (string, error) {
+	tmpl, ok := c.lookup(name, guildID, locale)
+	if !ok {
+		return "", fmt.Errorf("response template %q does not exist", name)
+	}
+	var out strings.Builder
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// Validate checks that every message used by the bot exists and that every loaded variant renders with sample data.
+func (c *Catalog) Validate() error {
+	var problems []string
+	for name := range templateSamples {
+		if _, ok := c.lookup(name, "", ""); !ok {
+			problems = append(problems, fmt.Sprintf("missing response template %q", name))
+		}
+	}
+	for guild, messages := range c.templates {
+		for name, locales := range messages {
+			for locale, tmpl := range locales {
+				if err := tmpl.Execute(new(strings.Builder), templateSamples[name]); err != nil {
+					problems = append(problems, fmt.Sprintf("response template %q (guild %q, locale %q) does not render: %v", name, guild, locale, err))
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return errors.New(strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// SetCatalog replaces the catalog used by Message and reports whether its templates differ from the previous one's.
+func SetCatalog(c *Catalog) (changed bool) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	changed = !catalog.equal(c)
+	catalog = c
+	return changed
+}
+
+// equal reports whether both catalogs have the same templates with the same content.
+func (c *Catalog) equal(other *Catalog) bool {
+	if len(c.templates) != len(other.templates) {
+		return false
+	}
+	for guild, messages := range c.templates {
+		if len(messages) != len(other.templates[guild]) {
+			return false
+		}
+		for name, locales := range messages {
+			if len(locales) != len(other.templates[guild][name]) {
+				return false
+			}
+			for locale, tmpl := range locales {
+				otherTmpl, ok := other.templates[guild][name][locale]
+				if !ok || tmpl.Tree.Root.String() != otherTmpl.Tree.Root.String() {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// Message renders a message from the catalog for the interaction's guild and locale.
+// If the message can't be rendered, the error is logged and the name of the message is returned instead.
+func Message(i *discordgo.Interaction, name string, data any) string {
+	var guildID string
+	var locale discordgo.Locale
+	if i != nil {
+		guildID, locale = i.GuildID, i.Locale
+	}
+
+	catalogMu.RLock()
+	c := catalog
+	catalogMu.RUnlock()
+
+	message, err := c.Render(name, guildID, locale, data)
+	if err != nil {
+		InteractionLogger(i).Error("Error rendering response template", "template", name, "err", err)
+		return name
+	}
+	return message
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// writeTemplates writes the files, by path relative to a temporary directory, and returns the directory.
+func writeTemplates(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestCatalogLocaleFallback(t *testing.T) {
+	c, err := LoadCatalog(writeTemplates(t, map[string]string{
+		"hello.tmpl":                  "Hello",
+		"hello.es-ES.tmpl":            "Hola",
+		"hello.pt.tmpl":               "Olá",
+		"hello.fr.tmpl":               "Bonjour",
+		"guilds/5000/hello.tmpl":      "Hello, guild",
+		"guilds/5000/hello.fr.tmpl":   "Bonjour, guild",
+		"guilds/not-a-dir.tmpl":       "ignored",
+		"guilds/6000/unrelated.tmpl":  "Unrelated",
+		"restarting.tmpl":             "Back soon",
+		"guilds/5000/restarting.tmpl": "Back soon, guild",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		guildID string
+		locale  discordgo.Locale
+		want    string
+	}{
+		{"default", MessageHello, "", "", "Hello"},
+		{"exact locale", MessageHello, "", discordgo.SpanishES, "Hola"},
+		{"language of the locale", MessageHello, "", discordgo.PortugueseBR, "Olá"},
+		{"locale without a template", MessageHello, "", discordgo.German, "Hello"},
+		{"other region", MessageHello, "", discordgo.Locale("es-419"), "Hello"},
+		{"guild default", MessageHello, "5000", "", "Hello, guild"},
+		{"guild locale", MessageHello, "5000", discordgo.French, "Bonjour, guild"},
+		// the guild's default message wins over a locale it doesn't override
+		{"guild without the locale", MessageHello, "5000", discordgo.SpanishES, "Hello, guild"},
+		{"guild without overrides", MessageHello, "6000", discordgo.SpanishES, "Hola"},
+		{"unknown guild", MessageRestarting, "7000", "", "Back soon"},
+		{"embedded default", MessageConfirmDelete, "5000", discordgo.French, mustRender(t, MessageConfirmDelete)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := c.Render(tt.message, tt.guildID, tt.locale, templateSamples[tt.message])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Render(%q, %q, %q) = %q, want %q", tt.message, tt.guildID, tt.locale, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := c.Render("no_such_message", "", "", nil); err == nil {
+		t.Error("rendering an unknown message succeeded")
+	}
+}
+
+// mustRender renders the embedded default of the message.
+func mustRender(t *testing.T, name string) string {
+	t.Helper()
+	c, err := LoadCatalog("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	message, err := c.Render(name, "", "", templateSamples[name])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return message
+}
+
+func TestCatalogValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{"defaults only", nil, ""},
+		{"overrides", map[string]string{"pagination_invalid_page.de.tmpl": "Seite 1 bis {{ .Pages }}"}, ""},
+		{"unknown field", map[string]string{"pagination_invalid_page.de.tmpl": "{{ .Total }}"}, `"pagination_invalid_page" (guild "", locale "de") does not render`},
+		{"unknown field in a guild", map[string]string{"guilds/5000/hello.tmpl": "{{ .Name }}"}, `"hello" (guild "5000", locale "") does not render`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := LoadCatalog(writeTemplates(t, tt.files))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = c.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Validate() = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() = %v, want an error containing %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadCatalogReportsParseErrors(t *testing.T) {
+	_, err := LoadCatalog(writeTemplates(t, map[string]string{
+		"hello.tmpl":      "{{ if }}",
+		"restarting.tmpl": "{{ .Unclosed",
+	}))
+	if err == nil || !strings.Contains(err.Error(), "hello.tmpl") || !strings.Contains(err.Error(), "restarting.tmpl") {
+		t.Errorf("LoadCatalog() = %v, want both broken files reported", err)
+	}
+}
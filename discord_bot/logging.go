@@ -0,0 +1,49 @@
+package discord_bot
+
+import (
+	"discordgo-basic/discord_bot/handlers"
+	"io"
+	stdlog "log"
+	"os"
+
+	"github.com/charmbracelet/log"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// NewLogger returns the logger configured by cfg, writing to stderr or the rotated log file with secrets redacted.
+// The returned io.Closer closes the log file, it is nil when logging to stderr.
+func NewLogger(cfg *Config) (*log.Logger, io.Closer) {
+	var out io.Writer = os.Stderr
+	var file io.Closer
+	if cfg.LogFile != "" {
+		rotated := &lumberjack.Logger{
+			Filename:   cfg.LogFile,
+			MaxSize:    cfg.LogRotation.MaxSize,
+			MaxBackups: cfg.LogRotation.MaxBackups,
+			MaxAge:     cfg.LogRotation.MaxAge,
+			Compress:   cfg.LogRotation.Compress,
+		}
+		out, file = rotated, rotated
+	}
+
+	formatter := log.TextFormatter
+	if cfg.LogFormat == "json" {
+		formatter = log.JSONFormatter
+	}
+	level, _ := log.ParseLevel(cfg.LogLevel)
+
+	return log.NewWithOptions(handlers.RedactingWriter(out), log.Options{
+		Level:           level,
+		Formatter:       formatter,
+		ReportTimestamp: true,
+	}), file
+}
+
+// useLogger makes logger the only logger: the default logger, the handlers package's and the standard library's,
+// which discordgo logs to.
+func useLogger(logger *log.Logger) {
+	log.SetDefault(logger)
+	handlers.SetLogger(logger)
+	stdlog.SetFlags(0)
+	stdlog.SetOutput(logger.StandardLog().Writer())
+}
@@ -0,0 +1,34 @@
+package discord_bot
+
+import (
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+// serveMetrics serves the expvar metrics at /debug/vars on addr, among them the redactions and REST retries
+// counted by the handlers package. It returns once the address is being listened on, the server's Addr is
+// the address listened on.
+func serveMetrics(addr string, logger *log.Logger) (*http.Server, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("cannot serve metrics: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+	server := &http.Server{Addr: listener.Addr().String(), Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Error serving metrics", "err", err)
+		}
+	}()
+
+	logger.Info("Serving metrics", "url", "http://"+server.Addr+"/debug/vars")
+	return server, nil
+}
@@ -0,0 +1,47 @@
+package discord_bot
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/charmbracelet/log"
+)
+
+func TestServeMetrics(t *testing.T) {
+	server, err := serveMetrics("127.0.0.1:0", log.New(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	resp, err := http.Get("http://" + server.Addr + "/debug/vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&vars); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"redactions", "rest_retries", "rest_failures"} {
+		if _, ok := vars[name]; !ok {
+			t.Errorf("%v isn't served", name)
+		}
+	}
+}
+
+func TestServeMetricsAddressInUse(t *testing.T) {
+	server, err := serveMetrics("127.0.0.1:0", log.New(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Shutdown(context.Background())
+
+	if _, err := serveMetrics(server.Addr, log.New(io.Discard)); err == nil {
+		t.Error("serveMetrics() on a used address succeeded")
+	}
+}
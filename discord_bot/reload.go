@@ -0,0 +1,229 @@
+package discord_bot
+
+import (
+	"discordgo-basic/discord_bot/handlers"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+// ReloadReport lists the settings a reload changed.
+type ReloadReport struct {
+	// Applied are the changed settings that are now in effect.
+	Applied []string
+	// Restart are the changed settings that only take effect once the bot is restarted.
+	Restart []string
+}
+
+func (r *ReloadReport) String() string {
+	report := "applied: " + listOrNone(r.Applied)
+	if len(r.Restart) > 0 {
+		report += ", restart required for: " + strings.Join(r.Restart, ", ")
+	}
+	return report
+}
+
+func listOrNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+// SetConfigLoader sets where Reload reads the configuration from.
+func (b *BotImpl) SetConfigLoader(l *ConfigLoader) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.loader = l
+}
+
+// Config returns the configuration in effect. It must not be modified.
+func (b *BotImpl) Config() *Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.config
+}
+
+// Reload reads the configuration again and applies what can be changed while running: the enabled commands
+// and their permissions, cooldowns, owners, response templates, help links, embed themes, error reporting, log level,
+// presence and shutdown timeout. Changes to settings that need a restart are listed in the report and otherwise ignored.
+//
+// Nothing is applied if the new configuration or templates are invalid. An error syncing the commands is
+// returned along with the report, as everything else has been applied by then.
+func (b *BotImpl) Reload() (*ReloadReport, error) {
+	b.reloadMu.Lock()
+	defer b.reloadMu.Unlock()
+
+	b.mu.RLock()
+	loader, running := b.loader, b.config
+	b.mu.RUnlock()
+	if loader == nil {
+		return nil, errors.New("the configuration can't be reloaded, no config loader was set")
+	}
+
+	cfg, err := loader.Load()
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := handlers.LoadCatalog(cfg.TemplateDir)
+	if err == nil {
+		err = catalog.Validate()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid response templates:\n%w", err)
+	}
+	// everything that can fail is checked before anything is applied
+	links := handlers.HelpLinks{Default: cfg.HelpURL, Codes: cfg.HelpURLs}
+	if err := links.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid help links:\n%w", err)
+	}
+
+	report := &ReloadReport{Restart: cfg.keepRestartSettings(running)}
+	applied := func(name string, changed bool) {
+		if changed {
+			report.Applied = append(report.Applied, name)
+		}
+	}
+
+	// the files may have changed even if the directory didn't, so the templates themselves are compared
+	applied("templates", handlers.SetCatalog(catalog))
+
+	_ = handlers.SetHelpLinks(links) // validated above
+	applied("help_url", !reflect.DeepEqual(links, handlers.HelpLinks{Default: running.HelpURL, Codes: running.HelpURLs}))
+
+	// validated by Load
+	themes, _ := cfg.guildThemes()
+	handlers.SetGuildThemes(themes)
+	applied("themes", !reflect.DeepEqual(cfg.Themes, running.Themes))
+
+	if cfg.ErrorChannelID != running.ErrorChannelID || cfg.ErrorWebhookURL != running.ErrorWebhookURL {
+		handlers.AddSecret(cfg.ErrorWebhookURL)
+		handlers.SetErrorReporter(newErrorReporter(b.botSession, cfg))
+		applied("error reporting", true)
+	}
+
+	level, _ := log.ParseLevel(cfg.LogLevel)
+	b.logger.SetLevel(level)
+	applied("log_level", cfg.LogLevel != running.LogLevel)
+
+	applied("cooldowns", !reflect.DeepEqual(cfg.Cooldowns, running.Cooldowns))
+	applied("owners", !slices.Equal(cfg.Owners, running.Owners))
+	applied("remove_commands", cfg.RemoveCommands != running.RemoveCommands)
+	applied("shutdown_timeout", cfg.ShutdownTimeout != running.ShutdownTimeout)
+
+	b.mu.Lock()
+	b.config = cfg
+	b.mu.Unlock()
+
+	var errs []error
+	if cfg.Presence != running.Presence {
+		if err := b.botSession.UpdateStatusComplex(presenceData(cfg.Presence)); err != nil {
+			errs = append(errs, fmt.Errorf("cannot update presence: %w", err))
+		}
+		applied("presence", true)
+	}
+
+	if !slices.Equal(cfg.Commands, running.Commands) ||
+		!reflect.DeepEqual(cfg.GuildCommands, running.GuildCommands) ||
+		!reflect.DeepEqual(cfg.Permissions, running.Permissions) {
+		if err := b.registerCommands(); err != nil {
+			errs = append(errs, err)
+		}
+		applied("commands", true)
+	}
+
+	return report, errors.Join(errs...)
+}
+
+// reloadFromSignal reloads the configuration and logs the outcome, as there is no one to reply to.
+func (b *BotImpl) reloadFromSignal() {
+	b.logger.Info("Reloading configuration")
+	report, err := b.Reload()
+	if report != nil {
+		b.logger.Info("Reloaded configuration", "applied", report.Applied, "restart_required", report.Restart)
+	}
+	if err != nil {
+		b.logger.Error("Error reloading configuration", "err", err)
+	}
+}
+
+// presenceData returns the status update setting the presence.
+func presenceData(p Presence) discordgo.UpdateStatusData {
+	data := discordgo.UpdateStatusData{Status: p.Status, Activities: []*discordgo.Activity{}}
+	if p.Activity == "" {
+		return data
+	}
+
+	activity := &discordgo.Activity{Name: p.Activity, Type: activityTypes[p.ActivityType]}
+	if activity.Type == discordgo.ActivityTypeCustom {
+		// custom statuses show the state, the name is ignored
+		activity.Name, activity.State = "Custom Status", p.Activity
+	}
+	data.Activities = append(data.Activities, activity)
+	return data
+}
+
+// newErrorReporter returns the reporter for the error channel or webhook in cfg, or nil if neither is set.
+// The webhook takes precedence.
+func newErrorReporter(session *discordgo.Session, cfg *Config) *handlers.ErrorReporter {
+	switch {
+	case cfg.ErrorWebhookURL != "":
+		return handlers.NewErrorReporter(handlers.WebhookSink{URL: cfg.ErrorWebhookURL})
+	case cfg.ErrorChannelID != "":
+		return handlers.NewErrorReporter(handlers.ChannelSink{Bot: session, ChannelID: cfg.ErrorChannelID})
+	}
+	return nil
+}
+
+// isOwner reports whether the user may use owner-only commands: one of Config.Owners or, if there are none,
+// the owner of the application or a member of the team owning it.
+func (b *BotImpl) isOwner(userID string) (bool, error) {
+	if owners := b.Config().Owners; len(owners) > 0 {
+		return slices.Contains(owners, userID), nil
+	}
+
+	app, err := b.botSession.Application("@me")
+	if err != nil {
+		return false, err
+	}
+	if app.Owner != nil && app.Owner.ID == userID {
+		return true, nil
+	}
+	if app.Team != nil {
+		for _, member := range app.Team.Members {
+			if member.User != nil && member.User.ID == userID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkCooldown returns an error if the user used the command less than its cooldown ago.
+func (b *BotImpl) checkCooldown(i *discordgo.Interaction) error {
+	name := i.ApplicationCommandData().Name
+	remaining := b.cooldowns.Take(name+"|"+handlers.UserID(i), b.Config().Cooldowns[name])
+	if remaining <= 0 {
+		return nil
+	}
+	return handlers.UserError("cooldown", fmt.Sprintf("You can use /%v again <t:%d:R>.", name, time.Now().Add(remaining).Unix()))
+}
+
+// reloadEmbed shows the outcome of /reload.
+func reloadEmbed(guildID string, report *ReloadReport) *handlers.EmbedBuilder {
+	embed := handlers.NewEmbed(guildID, handlers.StyleSuccess).
+		Title("Configuration reloaded").
+		Field("Applied", listOrNone(report.Applied), false).
+		Now()
+	if len(report.Restart) > 0 {
+		embed.Color(handlers.ThemeFor(guildID).Color(handlers.StyleWarning)).
+			Field("Restart required", strings.Join(report.Restart, ", "), false)
+	}
+	return embed
+}
@@ -0,0 +1,147 @@
+package discord_bot
+
+import (
+	"discordgo-basic/discord_bot/handlers"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/log"
+)
+
+func TestKeepRestartSettings(t *testing.T) {
+	running := DefaultConfig()
+	running.BotToken = testToken
+	running.GuildID = "5000"
+
+	tests := []struct {
+		name   string
+		change func(cfg *Config)
+		want   []string
+	}{
+		{"nothing", func(cfg *Config) {}, nil},
+		{"reloadable only", func(cfg *Config) { cfg.LogLevel, cfg.Owners = "debug", []string{"9000"} }, nil},
+		{"guild", func(cfg *Config) { cfg.GuildID = "6000" }, []string{"guild"}},
+		{"health interval", func(cfg *Config) { cfg.HealthInterval = time.Minute }, []string{"health_interval"}},
+		{"log rotation", func(cfg *Config) { cfg.LogRotation.MaxSize = 1 }, []string{"log_file"}},
+		{
+			"several",
+			func(cfg *Config) {
+				cfg.BotToken, cfg.Backends, cfg.MetricsAddr = "other", []handlers.Backend{{Name: "api"}}, "localhost:9090"
+			},
+			[]string{"token", "backends", "metrics_addr"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := *running
+			tt.change(&cfg)
+			reloadable := cfg.LogLevel
+
+			if got := cfg.keepRestartSettings(running); !slices.Equal(got, tt.want) {
+				t.Errorf("keepRestartSettings() = %v, want %v", got, tt.want)
+			}
+			if cfg.BotToken != running.BotToken || cfg.GuildID != running.GuildID || cfg.HealthInterval != running.HealthInterval ||
+				cfg.MetricsAddr != running.MetricsAddr || cfg.LogRotation != running.LogRotation || !reflect.DeepEqual(cfg.Backends, running.Backends) {
+				t.Errorf("the restart settings weren't reverted: %+v", cfg)
+			}
+			if cfg.LogLevel != reloadable {
+				t.Errorf("log level = %q, a reloadable setting was reverted", cfg.LogLevel)
+			}
+		})
+	}
+}
+
+// reloadableBot returns a bot running the config file, which Reload reads again.
+func reloadableBot(t *testing.T, path string) *BotImpl {
+	t.Helper()
+	clearEnv(t)
+	t.Setenv("BOT_TOKEN", testToken)
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := NewConfigLoader(fs)
+	if err := fs.Parse([]string{"-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loader.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reload replaces the package-level templates and help links
+	defaults, _ := handlers.LoadCatalog("")
+	handlers.SetCatalog(defaults)
+	t.Cleanup(func() {
+		handlers.SetCatalog(defaults)
+		_ = handlers.SetHelpLinks(handlers.HelpLinks{})
+	})
+
+	_, session := newFakeDiscord(t)
+	return &BotImpl{botSession: session, logger: log.New(io.Discard), config: cfg, loader: loader}
+}
+
+func TestReloadReport(t *testing.T) {
+	templates := t.TempDir()
+	if err := os.WriteFile(filepath.Join(templates, handlers.MessageHello+".tmpl"), []byte("Howdy!"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	broken := t.TempDir()
+	if err := os.WriteFile(filepath.Join(broken, handlers.MessageHello+".tmpl"), []byte("{{ .Missing"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	const initial = "guild: \"111111111111111111\"\nlog_level: info\n"
+	tests := []struct {
+		name        string
+		config      string
+		wantApplied []string
+		wantRestart []string
+		wantErr     bool
+	}{
+		{"unchanged", initial, nil, nil, false},
+		{"log level", "guild: \"111111111111111111\"\nlog_level: debug\n", []string{"log_level"}, nil, false},
+		{"templates", initial + "templates: " + templates + "\n", []string{"templates"}, nil, false},
+		{"restart only", "guild: \"222222222222222222\"\nlog_level: info\n", nil, []string{"guild"}, false},
+		{"help links", initial + "help_url: https://example.com/{code}\n", []string{"help_url"}, nil, false},
+		{"invalid templates", "guild: \"111111111111111111\"\nlog_level: debug\ntemplates: " + broken + "\n", nil, nil, true},
+		{"invalid help links", "guild: \"111111111111111111\"\nlog_level: debug\nhelp_url: not a link\n", nil, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, initial)
+			b := reloadableBot(t, path)
+			running := b.Config()
+			if err := os.WriteFile(path, []byte(tt.config), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			report, err := b.Reload()
+			if tt.wantErr {
+				if report != nil || err == nil {
+					t.Fatalf("Reload() = %v, %v, want nothing applied and an error", report, err)
+				}
+				if b.Config() != running || b.logger.GetLevel() != log.InfoLevel {
+					t.Error("a failed reload applied the config")
+				}
+				if message := handlers.Message(nil, handlers.MessageHello, nil); message == "Howdy!" {
+					t.Error("a failed reload applied the templates")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(report.Applied, tt.wantApplied) || !slices.Equal(report.Restart, tt.wantRestart) {
+				t.Errorf("Reload() applied %v and needs a restart for %v, want %v and %v", report.Applied, report.Restart, tt.wantApplied, tt.wantRestart)
+			}
+			if b.Config().GuildID != "111111111111111111" {
+				t.Errorf("guild = %v, a restart setting was applied", b.Config().GuildID)
+			}
+		})
+	}
+}
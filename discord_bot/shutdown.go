@@ -0,0 +1,121 @@
+package discord_bot
+
+import (
+	"context"
+	"discordgo-basic/discord_bot/handlers"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// inflight is an interaction whose handler is running.
+type inflight struct {
+	interaction *discordgo.Interaction
+	started     time.Time
+	ctx         context.Context
+	cancel      context.CancelFunc
+}
+
+// track registers the interaction as in flight. It returns false once the bot is shutting down.
+func (b *BotImpl) track(i *discordgo.Interaction) bool {
+	b.inflightMu.Lock()
+	defer b.inflightMu.Unlock()
+	if b.drained != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b.inflight[i.ID] = &inflight{interaction: i, started: time.Now(), ctx: ctx, cancel: cancel}
+	return true
+}
+
+// untrack marks the interaction's handler as finished.
+func (b *BotImpl) untrack(i *discordgo.Interaction) {
+	b.inflightMu.Lock()
+	defer b.inflightMu.Unlock()
+	if f, ok := b.inflight[i.ID]; ok {
+		f.cancel()
+		delete(b.inflight, i.ID)
+	}
+	if b.drained != nil && len(b.inflight) == 0 {
+		select {
+		case <-b.drained:
+		default:
+			close(b.drained)
+		}
+	}
+}
+
+// Context returns a context for the interaction that is cancelled when its handler returns,
+// or when the bot gives up waiting for it during shutdown. Handlers should pass it to anything long-running.
+func (b *BotImpl) Context(i *discordgo.Interaction) context.Context {
+	b.inflightMu.Lock()
+	defer b.inflightMu.Unlock()
+	if f, ok := b.inflight[i.ID]; ok {
+		return f.ctx
+	}
+	return context.Background()
+}
+
+// drain stops accepting new interactions and returns a channel closed once none are in flight.
+func (b *BotImpl) drain() <-chan struct{} {
+	b.inflightMu.Lock()
+	defer b.inflightMu.Unlock()
+	if b.drained == nil {
+		b.drained = make(chan struct{})
+		if len(b.inflight) == 0 {
+			close(b.drained)
+		}
+	}
+	return b.drained
+}
+
+// abandon cancels the contexts of the interactions still in flight and returns them, oldest first.
+func (b *BotImpl) abandon() []*inflight {
+	b.inflightMu.Lock()
+	defer b.inflightMu.Unlock()
+	abandoned := make([]*inflight, 0, len(b.inflight))
+	for _, f := range b.inflight {
+		f.cancel()
+		abandoned = append(abandoned, f)
+	}
+	sort.Slice(abandoned, func(a, c int) bool { return abandoned[a].started.Before(abandoned[c].started) })
+	return abandoned
+}
+
+// rejectInteraction tells the user the bot is restarting, autocomplete requests are left unanswered.
+func rejectInteraction(s *discordgo.Session, i *discordgo.Interaction) {
+	handlers.InteractionLogger(i).Info("Rejected interaction while shutting down")
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		return
+	}
+	handlers.Responses[handlers.EphemeralContent].(handlers.MsgResponseType)(s, i, handlers.Message(i, handlers.MessageRestarting, nil))
+}
+
+// Shutdown stops accepting interactions, answering new ones with a restarting message, and waits up to
+// Config.ShutdownTimeout for the handlers in flight to finish. The contexts of those still running are then
+// cancelled and they are logged as abandoned. Finally the bot is torn down.
+func (b *BotImpl) Shutdown() error {
+	timeout := b.Config().ShutdownTimeout
+	drained := b.drain()
+
+	b.inflightMu.Lock()
+	pending := len(b.inflight)
+	b.inflightMu.Unlock()
+	if pending > 0 {
+		b.logger.Info("Waiting for interactions in flight", "count", pending, "timeout", timeout)
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-drained:
+	case <-timer.C:
+		for _, f := range b.abandon() {
+			handlers.InteractionLogger(f.interaction).Warn("Abandoned interaction", "running_for", time.Since(f.started).Round(time.Millisecond))
+		}
+	}
+
+	return b.teardown()
+}
@@ -0,0 +1,143 @@
+package discord_bot
+
+import (
+	"discordgo-basic/discord_bot/handlers"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/charmbracelet/log"
+)
+
+// shutdownBot returns a bot that waits up to timeout for interactions in flight when shut down.
+func shutdownBot(t *testing.T, timeout time.Duration) *BotImpl {
+	t.Helper()
+	_, session := newFakeDiscord(t)
+	cfg := DefaultConfig()
+	cfg.ShutdownTimeout = timeout
+	return &BotImpl{
+		botSession: session,
+		logger:     log.New(io.Discard),
+		health:     handlers.NewHealthMonitor(),
+		config:     cfg,
+		inflight:   make(map[string]*inflight),
+	}
+}
+
+// closed reports whether the channel is closed, without waiting.
+func closed(c <-chan struct{}) bool {
+	select {
+	case <-c:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestDrainWaitsForInflight(t *testing.T) {
+	b := shutdownBot(t, time.Second)
+	first, second := &discordgo.Interaction{ID: "1"}, &discordgo.Interaction{ID: "2"}
+	if !b.track(first) || !b.track(second) {
+		t.Fatal("track() refused an interaction before the shutdown")
+	}
+
+	drained := b.drain()
+	if b.track(&discordgo.Interaction{ID: "3"}) {
+		t.Error("track() accepted an interaction while draining")
+	}
+	if b.drain() != drained {
+		t.Error("drain() returned a new channel the second time")
+	}
+
+	b.untrack(first)
+	if closed(drained) {
+		t.Fatal("drained with an interaction still in flight")
+	}
+	b.untrack(second)
+	if !closed(drained) {
+		t.Fatal("not drained once every interaction finished")
+	}
+	// untracking again must not close the channel twice
+	b.untrack(second)
+}
+
+func TestDrainWithNothingInflight(t *testing.T) {
+	if !closed(shutdownBot(t, time.Second).drain()) {
+		t.Error("drain() with nothing in flight isn't closed")
+	}
+}
+
+func TestAbandonCancelsOldestFirst(t *testing.T) {
+	b := shutdownBot(t, time.Second)
+	var interactions []*discordgo.Interaction
+	for _, id := range []string{"3", "1", "2"} {
+		i := &discordgo.Interaction{ID: id}
+		b.track(i)
+		interactions = append(interactions, i)
+		time.Sleep(time.Millisecond)
+	}
+	ctx := b.Context(interactions[0])
+
+	abandoned := b.abandon()
+	if len(abandoned) != 3 {
+		t.Fatalf("abandoned %v interactions, want 3", len(abandoned))
+	}
+	for n, f := range abandoned {
+		if f.interaction != interactions[n] {
+			t.Errorf("abandoned[%v] = %v, want %v", n, f.interaction.ID, interactions[n].ID)
+		}
+		if f.ctx.Err() == nil {
+			t.Errorf("the context of %v wasn't cancelled", f.interaction.ID)
+		}
+	}
+	if ctx.Err() == nil {
+		t.Error("the context handed to the handler wasn't cancelled")
+	}
+	if b.Context(&discordgo.Interaction{ID: "untracked"}).Err() != nil {
+		t.Error("an untracked interaction has a cancelled context")
+	}
+}
+
+func TestShutdownWaitsForHandlers(t *testing.T) {
+	b := shutdownBot(t, 5*time.Second)
+	i := &discordgo.Interaction{ID: "1"}
+	b.track(i)
+	ctx := b.Context(i)
+
+	cancelledEarly := make(chan bool, 1)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancelledEarly <- ctx.Err() != nil
+		b.untrack(i)
+	}()
+
+	start := time.Now()
+	if err := b.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Shutdown() took %v, want it to return once the handler finished", elapsed)
+	}
+	if <-cancelledEarly {
+		t.Error("the handler's context was cancelled before the timeout")
+	}
+}
+
+func TestShutdownAbandonsAfterTimeout(t *testing.T) {
+	b := shutdownBot(t, 50*time.Millisecond)
+	i := &discordgo.Interaction{ID: "1"}
+	b.track(i)
+	ctx := b.Context(i)
+
+	start := time.Now()
+	if err := b.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("Shutdown() took %v, want about the 50ms timeout", elapsed)
+	}
+	if ctx.Err() == nil {
+		t.Error("the context of the abandoned handler wasn't cancelled")
+	}
+}
@@ -2,75 +2,73 @@ package main
 
 import (
 	"discordgo-basic/discord_bot"
+	"errors"
 	"flag"
-	"github.com/joho/godotenv"
-	"log"
+	"fmt"
 	"os"
+
+	"github.com/charmbracelet/log"
 )
 
-// Bot parameters
 var (
-	guildID            = flag.String("guild", "", "Guild ID. If not passed - bot registers commands globally")
-	botToken           = flag.String("token", "", "Bot access token")
-	removeCommandsFlag = flag.Bool("remove", false, "Delete all commands when bot exits")
+	check      = flag.Bool("check", false, "Validate the configuration, commands and templates without connecting, then exit")
+	unregister = flag.Bool("unregister", false, "Remove every command of the application in the guild, or every global command without -guild, then exit")
 )
 
-func init() {
-	if err := godotenv.Load(); err != nil {
-		log.Printf("Error loading .env file: %v", err)
-	}
-	log.Println(".env file loaded successfully")
-
-	if botToken == nil || *botToken == "" {
-		tokenEnv := os.Getenv("BOT_TOKEN")
-		if tokenEnv == "YOUR_BOT_TOKEN_HERE" {
-			log.Fatalf("Invalid bot token: %v\n"+
-				"Did you edit the .env or run the program with -token ?", tokenEnv)
-		}
-		if tokenEnv != "" {
-			botToken = &tokenEnv
+func main() {
+	args := os.Args[1:]
+	// `validate` is the same as -check, `unregister` as -unregister
+	if len(args) > 0 {
+		switch args[0] {
+		case "validate":
+			args = args[1:]
+			*check = true
+		case "unregister":
+			args = args[1:]
+			*unregister = true
 		}
 	}
 
-	if guildID == nil || *guildID == "" {
-		guildEnv := os.Getenv("GUILD_ID")
-		if guildEnv != "" {
-			guildID = &guildEnv
+	loader := discord_bot.NewConfigLoader(flag.CommandLine)
+	if err := flag.CommandLine.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
 		}
+		log.Fatal(err)
 	}
+	cfg, err := loader.Load()
 
-	if removeCommandsFlag == nil || !*removeCommandsFlag {
-		removeCommandsEnv := os.Getenv("REMOVE_COMMANDS")
-		if removeCommandsEnv != "" {
-			removeCommandsFlag = new(bool)
-			*removeCommandsFlag = removeCommandsEnv == "true"
+	if *check {
+		if cfg == nil {
+			log.Fatal(err)
+		}
+		report := discord_bot.Check(cfg, err)
+		fmt.Println(report)
+		if !report.OK() {
+			os.Exit(1)
 		}
+		return
 	}
-}
-
-func main() {
-	flag.Parse()
 
-	if botToken == nil || *botToken == "" {
-		log.Fatalf("Bot token flag is required")
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	var removeCommands bool
-
-	if removeCommandsFlag != nil && *removeCommandsFlag {
-		removeCommands = *removeCommandsFlag
+	if *unregister {
+		if err := discord_bot.UnregisterCommands(cfg); err != nil {
+			log.Fatal("Error unregistering commands", "err", err)
+		}
+		return
 	}
 
-	bot, err := discord_bot.New(&discord_bot.Config{
-		BotToken:       *botToken,
-		GuildID:        *guildID,
-		RemoveCommands: removeCommands,
-	})
+	bot, err := discord_bot.New(cfg)
 	if err != nil {
-		log.Fatalf("Error creating Discord bot: %v", err)
+		log.Fatal("Error creating Discord bot", "err", err)
 	}
+	// SIGHUP and /reload read the configuration again
+	bot.SetConfigLoader(loader)
 
 	bot.Start()
 
-	log.Println("Gracefully shutting down.")
+	log.Info("Gracefully shutting down")
 }